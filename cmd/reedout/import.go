@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/reedfamily/reedout/internal/config"
+	"github.com/reedfamily/reedout/internal/egg"
+)
+
+// importEgg implements "reedout import-egg [flags] egg.json".
+func importEgg(args []string) int {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return 1
+	}
+
+	fs := flag.NewFlagSet("import-egg", flag.ContinueOnError)
+	var opts egg.Options
+	fs.StringVar(&opts.ID, "id", "", "template id (default: derived from the egg name)")
+	fs.StringVar(&opts.Game, "game", "", "game name (default: the template id)")
+	fs.IntVar(&opts.Port, "port", 0, "primary port to publish")
+	fs.StringVar(&opts.Protocol, "protocol", "tcp", "protocol of the primary port")
+	fs.StringVar(&opts.Memory, "memory", "2G", "default memory limit")
+	dir := fs.String("dir", cfg.TemplatePath, "template directory to write to")
+	dryRun := fs.Bool("dry-run", false, "print the template instead of saving it")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: reedout import-egg [flags] egg.json")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read egg: %v\n", err)
+		return 1
+	}
+	res, err := egg.Convert(data, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	if err := res.Template.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "converted template is invalid: %v\n", err)
+		return 1
+	}
+	for _, w := range res.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+
+	if *dryRun {
+		out, _ := json.MarshalIndent(res.Template, "", "  ")
+		fmt.Println(string(out))
+		return 0
+	}
+	path, err := res.Save(*dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "save template: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Imported %q as %s\n", res.Template.Name, path)
+	return 0
+}
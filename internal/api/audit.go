@@ -0,0 +1,26 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/reedfamily/reedout/internal/audit"
+)
+
+// AuditHandler exposes the audit trail to admins.
+type AuditHandler struct {
+	audit *audit.Service
+}
+
+func NewAuditHandler(auditSvc *audit.Service) *AuditHandler {
+	return &AuditHandler{audit: auditSvc}
+}
+
+// List returns recent audit entries, optionally filtered by ?server_id=.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	entries, err := h.audit.List(r.URL.Query().Get("server_id"), 200)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list audit log")
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
@@ -1,15 +1,23 @@
 package api
 
 import (
+	"context"
 	"database/sql"
 	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
 	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/storage"
 )
 
 var upgrader = websocket.Upgrader{
@@ -17,12 +25,13 @@ var upgrader = websocket.Upgrader{
 }
 
 type ConsoleHandler struct {
-	db     *sql.DB
-	docker *docker.Client
+	db      *sql.DB
+	docker  *docker.Client
+	storage *storage.Service
 }
 
-func NewConsoleHandler(db *sql.DB, dockerClient *docker.Client) *ConsoleHandler {
-	return &ConsoleHandler{db: db, docker: dockerClient}
+func NewConsoleHandler(db *sql.DB, dockerClient *docker.Client, storageSvc *storage.Service) *ConsoleHandler {
+	return &ConsoleHandler{db: db, docker: dockerClient, storage: storageSvc}
 }
 
 func (h *ConsoleHandler) Handle(w http.ResponseWriter, r *http.Request) {
@@ -128,3 +137,115 @@ func (h *ConsoleHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+// commandTimeout bounds a remote console command.
+const commandTimeout = 10 * time.Second
+
+// Command runs a console command and returns its output. Games whose adapter
+// speaks a remote console protocol (such as RCON) answer with the output;
+// for others the command is written to the server's stdin and the output
+// shows up in the console stream instead.
+func (h *ConsoleHandler) Command(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req struct {
+		Command string `json:"command"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Command = strings.TrimSpace(req.Command)
+	if req.Command == "" || strings.ContainsAny(req.Command, "\r\n") {
+		writeError(w, http.StatusBadRequest, "command must be a single non-empty line")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), commandTimeout)
+	defer cancel()
+	gameID, containerID, ep, err := serverEndpoint(ctx, h.db, h.docker, h.storage, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	if commander, ok := game.Get(gameID).(game.Commander); ok {
+		out, err := commander.Command(ctx, ep, req.Command)
+		switch {
+		case errors.Is(err, game.ErrNotAvailable):
+			// This server has no remote console; use stdin
+		case err != nil:
+			writeError(w, http.StatusBadGateway, fmt.Sprintf("command failed: %v", err))
+			return
+		default:
+			writeJSON(w, http.StatusOK, map[string]string{"output": out, "via": "rcon"})
+			return
+		}
+	}
+
+	if err := writeStdin(ctx, h.docker, containerID, req.Command); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"output": "", "via": "stdin"})
+}
+
+// writeStdin sends a command line to a container's stdin.
+func writeStdin(ctx context.Context, dockerClient *docker.Client, containerID, command string) error {
+	attach, err := dockerClient.ContainerAttach(ctx, containerID)
+	if err != nil {
+		return errors.New("failed to attach to container")
+	}
+	defer attach.Close()
+	if _, err := attach.Conn.Write([]byte(command + "\n")); err != nil {
+		return errors.New("failed to send command")
+	}
+	return nil
+}
+
+// serverEndpoint looks up how to reach a running server. It returns
+// sql.ErrNoRows for unknown servers and an error when it isn't running.
+func serverEndpoint(ctx context.Context, db *sql.DB, dockerClient *docker.Client, storageSvc *storage.Service, id string) (string, string, game.Endpoint, error) {
+	var gameID, containerID, portsJSON, envJSON string
+	err := db.QueryRow("SELECT game, container_id, ports, env FROM servers WHERE id = ?", id).
+		Scan(&gameID, &containerID, &portsJSON, &envJSON)
+	if err != nil {
+		return "", "", game.Endpoint{}, err
+	}
+	if containerID == "" {
+		return "", "", game.Endpoint{}, errors.New("server is not running")
+	}
+	if status, err := dockerClient.ContainerStatus(ctx, containerID); err != nil || status != "running" {
+		return "", "", game.Endpoint{}, errors.New("server is not running")
+	}
+	ip, err := dockerClient.ContainerIP(ctx, containerID)
+	if err != nil {
+		return "", "", game.Endpoint{}, err
+	}
+
+	var ports []docker.PortMapping
+	json.Unmarshal([]byte(portsJSON), &ports)
+	docker.NamePorts(ports)
+	ep := game.Endpoint{
+		IP:    ip,
+		Ports: map[string]string{},
+		Env:   map[string]string{},
+		Console: func(ctx context.Context, line string) error {
+			return writeStdin(ctx, dockerClient, containerID, line)
+		},
+	}
+	for _, p := range ports {
+		if p.Role != "" && ep.Ports[p.Role] == "" {
+			ep.Ports[p.Role] = docker.FirstPort(p.Container)
+		}
+	}
+	json.Unmarshal([]byte(envJSON), &ep.Env)
+	if files, err := storageSvc.ForServer(id); err == nil {
+		ep.Files = files
+	}
+	return gameID, containerID, ep, nil
+}
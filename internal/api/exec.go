@@ -0,0 +1,100 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/reedfamily/reedout/internal/audit"
+	"github.com/reedfamily/reedout/internal/docker"
+)
+
+const (
+	execDefaultTimeout = 30 * time.Second
+	execMaxTimeout     = 5 * time.Minute
+	execDefaultOutput  = 1 << 20
+	execMaxOutput      = 8 << 20
+)
+
+type ExecHandler struct {
+	db     *sql.DB
+	docker *docker.Client
+	audit  *audit.Service
+}
+
+func NewExecHandler(db *sql.DB, dockerClient *docker.Client, auditSvc *audit.Service) *ExecHandler {
+	return &ExecHandler{db: db, docker: dockerClient, audit: auditSvc}
+}
+
+// Run executes a one-off command inside a running server's container and
+// returns its output. Admin only.
+func (h *ExecHandler) Run(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req struct {
+		Command   []string `json:"command"`
+		Timeout   string   `json:"timeout"`
+		MaxOutput int      `json:"max_output"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.Command) == 0 || req.Command[0] == "" {
+		writeError(w, http.StatusBadRequest, "command required")
+		return
+	}
+
+	timeout := execDefaultTimeout
+	if req.Timeout != "" {
+		d, err := time.ParseDuration(req.Timeout)
+		if err != nil || d <= 0 || d > execMaxTimeout {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid timeout: use a duration up to %s", execMaxTimeout))
+			return
+		}
+		timeout = d
+	}
+	maxOutput := execDefaultOutput
+	if req.MaxOutput != 0 {
+		if req.MaxOutput < 0 || req.MaxOutput > execMaxOutput {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("max_output must be between 1 and %d bytes", execMaxOutput))
+			return
+		}
+		maxOutput = req.MaxOutput
+	}
+
+	var containerID string
+	if err := h.db.QueryRow("SELECT container_id FROM servers WHERE id = ?", id).Scan(&containerID); err != nil || containerID == "" {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	status, err := h.docker.ContainerStatus(r.Context(), containerID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to inspect container")
+		return
+	}
+	if status != "running" {
+		writeError(w, http.StatusConflict, "server is not running")
+		return
+	}
+
+	// Make sure a slow command doesn't trip the server's write timeout first
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 10*time.Second))
+
+	if user := currentUser(r); user != nil {
+		h.audit.Record(user.ID, user.Username, "exec", id, strings.Join(req.Command, " "))
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	result, err := h.docker.Exec(ctx, containerID, req.Command, maxOutput)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "exec failed: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/reedfamily/reedout/internal/storage"
+)
+
+// maxFileUpload caps the size of files written through the API.
+const maxFileUpload = 50 << 20
+
+type FileHandler struct {
+	storage *storage.Service
+}
+
+func NewFileHandler(storageSvc *storage.Service) *FileHandler {
+	return &FileHandler{storage: storageSvc}
+}
+
+// List returns the entries of a directory in the server's data.
+func (h *FileHandler) List(w http.ResponseWriter, r *http.Request) {
+	vol, ok := h.volume(w, r)
+	if !ok {
+		return
+	}
+	files, err := vol.ReadDir(r.Context(), r.URL.Query().Get("path"))
+	if err != nil {
+		writeFileError(w, err, "failed to list directory")
+		return
+	}
+	writeJSON(w, http.StatusOK, files)
+}
+
+// Read sends the raw contents of a file.
+func (h *FileHandler) Read(w http.ResponseWriter, r *http.Request) {
+	vol, ok := h.volume(w, r)
+	if !ok {
+		return
+	}
+	name := r.URL.Query().Get("path")
+	data, err := vol.ReadFile(r.Context(), name)
+	if err != nil {
+		writeFileError(w, err, "failed to read file")
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", "attachment; filename="+path.Base(name))
+	w.Write(data)
+}
+
+// Write replaces a file with the request body.
+func (h *FileHandler) Write(w http.ResponseWriter, r *http.Request) {
+	vol, ok := h.volume(w, r)
+	if !ok {
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxFileUpload))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "file too large")
+		return
+	}
+	if err := vol.WriteFile(r.Context(), r.URL.Query().Get("path"), data); err != nil {
+		writeFileError(w, err, "failed to write file")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "file saved"})
+}
+
+// Delete removes a file or directory.
+func (h *FileHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vol, ok := h.volume(w, r)
+	if !ok {
+		return
+	}
+	if err := vol.Remove(r.Context(), r.URL.Query().Get("path")); err != nil {
+		writeFileError(w, err, "failed to delete file")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "file deleted"})
+}
+
+func (h *FileHandler) volume(w http.ResponseWriter, r *http.Request) (storage.Volume, bool) {
+	vol, err := h.storage.ForServer(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "server not found")
+		return nil, false
+	}
+	return vol, true
+}
+
+func writeFileError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		writeError(w, http.StatusNotFound, "file not found")
+	case errors.Is(err, storage.ErrRoot):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, msg+": "+err.Error())
+	}
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/storage"
+)
+
+// GameHandler exposes the optional features of a server's game adapter.
+type GameHandler struct {
+	db      *sql.DB
+	docker  *docker.Client
+	storage *storage.Service
+}
+
+func NewGameHandler(db *sql.DB, dockerClient *docker.Client, storageSvc *storage.Service) *GameHandler {
+	return &GameHandler{db: db, docker: dockerClient, storage: storageSvc}
+}
+
+// Capabilities reports which game features a server supports.
+func (h *GameHandler) Capabilities(w http.ResponseWriter, r *http.Request) {
+	var gameID string
+	err := h.db.QueryRow("SELECT game FROM servers WHERE id = ?", chi.URLParam(r, "id")).Scan(&gameID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, game.CapabilitiesOf(gameID))
+}
+
+// Save makes a running server write its world to disk, through the adapter
+// or by writing the game's save command to stdin.
+func (h *GameHandler) Save(w http.ResponseWriter, r *http.Request) {
+	h.withAdapter(w, r, func(ctx context.Context, a game.GameAdapter, containerID string, ep game.Endpoint) (bool, error) {
+		if s, ok := a.(game.SaveController); ok {
+			return true, s.Save(ctx, ep)
+		}
+		if s, ok := a.(game.SaveCommander); ok && s.SaveCommand() != "" {
+			return true, writeStdin(ctx, h.docker, containerID, s.SaveCommand())
+		}
+		return false, nil
+	})
+}
+
+// Broadcast sends a message to every player on a running server. Body:
+// {"message"}.
+func (h *GameHandler) Broadcast(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Message string `json:"message"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" || strings.ContainsAny(req.Message, "\r\n") {
+		writeError(w, http.StatusBadRequest, "message must be a single non-empty line")
+		return
+	}
+	h.withAdapter(w, r, func(ctx context.Context, a game.GameAdapter, _ string, ep game.Endpoint) (bool, error) {
+		b, ok := a.(game.Broadcaster)
+		if !ok {
+			return false, nil
+		}
+		return true, b.Broadcast(ctx, ep, req.Message)
+	})
+}
+
+// Kick removes a player from a running server. Body: {"reason"} (optional).
+func (h *GameHandler) Kick(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, func(ctx context.Context, m game.PlayerManager, ep game.Endpoint, player, reason string) error {
+		return m.Kick(ctx, ep, player, reason)
+	})
+}
+
+// Ban bans a player from a running server. Body: {"reason"} (optional).
+func (h *GameHandler) Ban(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, func(ctx context.Context, m game.PlayerManager, ep game.Endpoint, player, reason string) error {
+		return m.Ban(ctx, ep, player, reason)
+	})
+}
+
+// Unban lifts a player's ban on a running server.
+func (h *GameHandler) Unban(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, func(ctx context.Context, m game.PlayerManager, ep game.Endpoint, player, _ string) error {
+		return m.Unban(ctx, ep, player)
+	})
+}
+
+func (h *GameHandler) moderate(w http.ResponseWriter, r *http.Request, fn func(context.Context, game.PlayerManager, game.Endpoint, string, string) error) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+	if strings.ContainsAny(req.Reason, "\r\n") {
+		writeError(w, http.StatusBadRequest, "reason must be a single line")
+		return
+	}
+	player := chi.URLParam(r, "player")
+	h.withAdapter(w, r, func(ctx context.Context, a game.GameAdapter, _ string, ep game.Endpoint) (bool, error) {
+		m, ok := a.(game.PlayerManager)
+		if !ok {
+			return false, nil
+		}
+		return true, fn(ctx, m, ep, player, req.Reason)
+	})
+}
+
+// withAdapter resolves a running server's adapter and endpoint and runs fn,
+// which reports whether the adapter supports the feature.
+func (h *GameHandler) withAdapter(w http.ResponseWriter, r *http.Request, fn func(context.Context, game.GameAdapter, string, game.Endpoint) (bool, error)) {
+	ctx, cancel := context.WithTimeout(r.Context(), commandTimeout)
+	defer cancel()
+	gameID, containerID, ep, err := serverEndpoint(ctx, h.db, h.docker, h.storage, chi.URLParam(r, "id"))
+	if errors.Is(err, sql.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	a := game.Get(gameID)
+	if a == nil {
+		writeError(w, http.StatusNotImplemented, "this game does not support that")
+		return
+	}
+	supported, err := fn(ctx, a, containerID, ep)
+	if errors.Is(err, game.ErrNotAvailable) {
+		writeError(w, http.StatusNotImplemented, err.Error())
+		return
+	}
+	if !supported {
+		writeError(w, http.StatusNotImplemented, "this game does not support that")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Sprintf("game server: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/install"
+)
+
+type InstallHandler struct {
+	docker   *docker.Client
+	installs *install.Service
+	servers  *ServerHandler
+}
+
+func NewInstallHandler(dockerClient *docker.Client, installSvc *install.Service, serverHandler *ServerHandler) *InstallHandler {
+	return &InstallHandler{docker: dockerClient, installs: installSvc, servers: serverHandler}
+}
+
+// Get returns the latest install run for a server.
+func (h *InstallHandler) Get(w http.ResponseWriter, r *http.Request) {
+	inst, err := h.installs.Latest(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query installs")
+		return
+	}
+	if inst == nil {
+		writeError(w, http.StatusNotFound, "server has not been installed")
+		return
+	}
+	writeJSON(w, http.StatusOK, inst)
+}
+
+// Logs returns the output of the latest install run as plain text.
+func (h *InstallHandler) Logs(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "id")
+	inst, err := h.installs.Latest(serverID)
+	if err != nil || inst == nil {
+		writeError(w, http.StatusNotFound, "server has not been installed")
+		return
+	}
+	data, err := h.installs.Log(serverID, inst.ID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "install log not found")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write(data)
+}
+
+// Live streams the output of a running install over a WebSocket. If no
+// install is running, the latest install's log is sent and the socket closed.
+func (h *InstallHandler) Live(w http.ResponseWriter, r *http.Request) {
+	serverID := chi.URLParam(r, "id")
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("install websocket upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	backlog, ch, ok := h.installs.Subscribe(serverID)
+	if !ok {
+		if inst, err := h.installs.Latest(serverID); err == nil && inst != nil {
+			if data, err := h.installs.Log(serverID, inst.ID); err == nil {
+				conn.WriteMessage(websocket.TextMessage, data)
+			}
+		}
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "no install running"))
+		return
+	}
+	defer h.installs.Unsubscribe(serverID, ch)
+
+	if len(backlog) > 0 {
+		if err := conn.WriteMessage(websocket.TextMessage, backlog); err != nil {
+			return
+		}
+	}
+
+	// Read from client to detect disconnect
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case chunk, ok := <-ch:
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "install finished"))
+				return
+			}
+			if err := conn.WriteMessage(websocket.TextMessage, chunk); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// Reinstall runs the server's install script again. The server must be stopped.
+func (h *InstallHandler) Reinstall(w http.ResponseWriter, r *http.Request) {
+	s, err := h.servers.getServer(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	if s.Install == nil {
+		writeError(w, http.StatusBadRequest, "server has no install script")
+		return
+	}
+	if s.ContainerID != "" {
+		if status, err := h.docker.ContainerStatus(r.Context(), s.ContainerID); err == nil && status == "running" {
+			writeError(w, http.StatusConflict, "stop the server before reinstalling")
+			return
+		}
+	}
+
+	inst, err := h.installs.Run(s.ID)
+	if err != nil {
+		writeError(w, http.StatusConflict, "failed to start install: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusAccepted, inst)
+}
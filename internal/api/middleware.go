@@ -18,15 +18,51 @@ func AuthMiddleware(authSvc *auth.Service) func(http.Handler) http.Handler {
 				writeError(w, http.StatusUnauthorized, "missing authorization header")
 				return
 			}
+			authenticate(authSvc, token, next, w, r)
+		})
+	}
+}
 
-			user, err := authSvc.ValidateSession(token)
-			if err != nil {
-				writeError(w, http.StatusUnauthorized, "invalid or expired session")
+// WebSocketAuthMiddleware authenticates with a ?token= query parameter,
+// since browsers can't set headers on WebSocket requests.
+func WebSocketAuthMiddleware(authSvc *auth.Service) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.URL.Query().Get("token")
+			if token == "" {
+				writeError(w, http.StatusUnauthorized, "missing token")
 				return
 			}
-
-			ctx := context.WithValue(r.Context(), userContextKey{}, user)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			authenticate(authSvc, token, next, w, r)
 		})
 	}
 }
+
+func authenticate(authSvc *auth.Service, token string, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	user, err := authSvc.ValidateSession(token)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid or expired session")
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), userContextKey{}, user)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// currentUser returns the authenticated user, or nil.
+func currentUser(r *http.Request) *auth.User {
+	user, _ := r.Context().Value(userContextKey{}).(*auth.User)
+	return user
+}
+
+// RequireAdmin rejects requests from users who are not admins. It must run
+// after AuthMiddleware.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user := currentUser(r); user == nil || !user.Admin {
+			writeError(w, http.StatusForbidden, "admin access required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/reedfamily/reedout/internal/docker"
+)
+
+type NetworkHandler struct {
+	db     *sql.DB
+	docker *docker.Client
+}
+
+// Network is a named shared network. Servers on the same shared network can
+// reach each other by alias; shared networks have no outside access.
+type Network struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	DockerName  string   `json:"docker_name"`
+	Members     []string `json:"members"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+func NewNetworkHandler(db *sql.DB, dockerClient *docker.Client) *NetworkHandler {
+	return &NetworkHandler{db: db, docker: dockerClient}
+}
+
+// List returns all shared networks with the servers attached to them.
+func (h *NetworkHandler) List(w http.ResponseWriter, r *http.Request) {
+	members, err := networkMembers(h.db)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query servers")
+		return
+	}
+
+	rows, err := h.db.Query(`SELECT name, description, created_at FROM networks ORDER BY name`)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list networks")
+		return
+	}
+	defer rows.Close()
+
+	networks := []Network{}
+	for rows.Next() {
+		var n Network
+		if err := rows.Scan(&n.Name, &n.Description, &n.CreatedAt); err != nil {
+			continue
+		}
+		n.DockerName = docker.SharedNetwork(n.Name)
+		n.Members = members[n.Name]
+		if n.Members == nil {
+			n.Members = []string{}
+		}
+		networks = append(networks, n)
+	}
+	writeJSON(w, http.StatusOK, networks)
+}
+
+// Create adds a shared network.
+func (h *NetworkHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !docker.ValidNetworkName(req.Name) {
+		writeError(w, http.StatusBadRequest, "name must be lowercase letters, digits, '.', '_' or '-'")
+		return
+	}
+
+	if err := h.docker.EnsureNetwork(r.Context(), docker.SharedNetwork(req.Name), true); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if _, err := h.db.Exec(`INSERT INTO networks (name, description) VALUES (?, ?)`, req.Name, req.Description); err != nil {
+		writeError(w, http.StatusConflict, "network already exists")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, Network{
+		Name:        req.Name,
+		Description: req.Description,
+		DockerName:  docker.SharedNetwork(req.Name),
+		Members:     []string{},
+		CreatedAt:   time.Now().UTC().Format(time.RFC3339),
+	})
+}
+
+// Delete removes a shared network. It must not have any members.
+func (h *NetworkHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	members, err := networkMembers(h.db)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query servers")
+		return
+	}
+	if len(members[name]) > 0 {
+		writeError(w, http.StatusConflict, "network still has servers attached")
+		return
+	}
+
+	result, err := h.db.Exec(`DELETE FROM networks WHERE name = ?`, name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete network")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		writeError(w, http.StatusNotFound, "network not found")
+		return
+	}
+	if err := h.docker.RemoveNetwork(r.Context(), docker.SharedNetwork(name)); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "network deleted"})
+}
+
+// SetNetworks replaces the shared networks a server belongs to, connecting
+// and disconnecting the container live.
+func (h *ServerHandler) SetNetworks(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var req struct {
+		Networks []string `json:"networks"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Networks == nil {
+		req.Networks = []string{}
+	}
+
+	s, err := h.getServer(id)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	if err := h.checkNetworks(req.Networks); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if s.ContainerID != "" {
+		for _, n := range req.Networks {
+			if slices.Contains(s.Networks, n) {
+				continue
+			}
+			if err := h.docker.EnsureNetwork(r.Context(), docker.SharedNetwork(n), true); err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if err := h.docker.ConnectNetwork(r.Context(), docker.SharedNetwork(n), s.ContainerID, serverAliases(s.ID, s.Name)); err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+		for _, n := range s.Networks {
+			if slices.Contains(req.Networks, n) {
+				continue
+			}
+			if err := h.docker.DisconnectNetwork(r.Context(), docker.SharedNetwork(n), s.ContainerID); err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+		}
+	}
+
+	networksJSON, _ := json.Marshal(req.Networks)
+	if _, err := h.db.Exec("UPDATE servers SET networks = ?, updated_at = ? WHERE id = ?", string(networksJSON), time.Now(), id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update server")
+		return
+	}
+	s, _ = h.getServer(id)
+	writeJSON(w, http.StatusOK, s)
+}
+
+// refreshAliases reconnects a server's container to its shared networks so
+// other servers reach it under its current name. Aliases on its own network
+// are updated when the container is next created.
+func (h *ServerHandler) refreshAliases(ctx context.Context, s Server) error {
+	if s.ContainerID == "" {
+		return nil
+	}
+	for _, n := range s.Networks {
+		if err := h.docker.DisconnectNetwork(ctx, docker.SharedNetwork(n), s.ContainerID); err != nil {
+			return err
+		}
+		if err := h.docker.ConnectNetwork(ctx, docker.SharedNetwork(n), s.ContainerID, serverAliases(s.ID, s.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNetworks verifies that every named shared network exists.
+func (h *ServerHandler) checkNetworks(names []string) error {
+	for _, n := range names {
+		var exists int
+		if err := h.db.QueryRow(`SELECT COUNT(*) FROM networks WHERE name = ?`, n).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to look up network %s", n)
+		}
+		if exists == 0 {
+			return fmt.Errorf("network %q does not exist", n)
+		}
+	}
+	return nil
+}
+
+// serverAliases are the names other containers use to reach a server on a shared network.
+func serverAliases(id, name string) []string {
+	aliases := []string{id}
+	if alias := docker.NetworkAlias(name); alias != "" && alias != id {
+		aliases = append(aliases, alias)
+	}
+	return aliases
+}
+
+// networkMembers maps shared network names to the IDs of servers on them.
+func networkMembers(db *sql.DB) (map[string][]string, error) {
+	rows, err := db.Query(`SELECT id, networks FROM servers`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := map[string][]string{}
+	for rows.Next() {
+		var id, networksJSON string
+		if err := rows.Scan(&id, &networksJSON); err != nil {
+			continue
+		}
+		var networks []string
+		json.Unmarshal([]byte(networksJSON), &networks)
+		for _, n := range networks {
+			members[n] = append(members[n], id)
+		}
+	}
+	return members, nil
+}
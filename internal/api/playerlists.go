@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/storage"
+)
+
+// PlayerListHandler manages player lists such as admins, bans and
+// allowlists for games whose adapter supports them. Changes are applied
+// live when the server is running and the game allows it.
+type PlayerListHandler struct {
+	db      *sql.DB
+	docker  *docker.Client
+	storage *storage.Service
+}
+
+func NewPlayerListHandler(db *sql.DB, dockerClient *docker.Client, storageSvc *storage.Service) *PlayerListHandler {
+	return &PlayerListHandler{db: db, docker: dockerClient, storage: storageSvc}
+}
+
+func (h *PlayerListHandler) Get(w http.ResponseWriter, r *http.Request) {
+	l, files, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	entries, err := l.ReadPlayerList(r.Context(), files, chi.URLParam(r, "list"))
+	if err != nil {
+		writePlayerListError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// Add puts a player on a list. Body: a list entry; which fields are needed
+// depends on the game.
+func (h *PlayerListHandler) Add(w http.ResponseWriter, r *http.Request) {
+	var entry game.ListEntry
+	if err := decodeJSON(r, &entry); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	l, files, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), commandTimeout)
+	defer cancel()
+	if err := l.AddToPlayerList(ctx, files, h.endpoint(ctx, r), chi.URLParam(r, "list"), entry); err != nil {
+		writePlayerListError(w, err)
+		return
+	}
+	h.Get(w, r)
+}
+
+// Remove takes a player, by ID or name, off a list.
+func (h *PlayerListHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	player, err := url.PathUnescape(chi.URLParam(r, "entry"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid player")
+		return
+	}
+	l, files, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), commandTimeout)
+	defer cancel()
+	if err := l.RemoveFromPlayerList(ctx, files, h.endpoint(ctx, r), chi.URLParam(r, "list"), player); err != nil {
+		writePlayerListError(w, err)
+		return
+	}
+	h.Get(w, r)
+}
+
+func (h *PlayerListHandler) lookup(w http.ResponseWriter, r *http.Request) (game.PlayerLists, storage.Volume, bool) {
+	id := chi.URLParam(r, "id")
+	var gameID string
+	if err := h.db.QueryRow("SELECT game FROM servers WHERE id = ?", id).Scan(&gameID); err != nil {
+		writeError(w, http.StatusNotFound, "server not found")
+		return nil, nil, false
+	}
+	l, ok := game.Get(gameID).(game.PlayerLists)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "this game does not support player lists")
+		return nil, nil, false
+	}
+	files, err := h.storage.ForServer(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to open server data")
+		return nil, nil, false
+	}
+	return l, files, true
+}
+
+// endpoint returns how to reach the server, or nil if it isn't running.
+func (h *PlayerListHandler) endpoint(ctx context.Context, r *http.Request) *game.Endpoint {
+	_, _, ep, err := serverEndpoint(ctx, h.db, h.docker, h.storage, chi.URLParam(r, "id"))
+	if err != nil {
+		return nil
+	}
+	return &ep
+}
+
+func writePlayerListError(w http.ResponseWriter, err error) {
+	if errors.Is(err, game.ErrUnknownList) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeError(w, http.StatusBadRequest, fmt.Sprintf("player list: %v", err))
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/reedfamily/reedout/internal/players"
+)
+
+type PlayerHandler struct {
+	tracker *players.Tracker
+}
+
+func NewPlayerHandler(tracker *players.Tracker) *PlayerHandler {
+	return &PlayerHandler{tracker: tracker}
+}
+
+// Online lists the players currently on a server.
+func (h *PlayerHandler) Online(w http.ResponseWriter, r *http.Request) {
+	sessions, err := h.tracker.Online(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query players")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"count": len(sessions), "players": sessions})
+}
+
+// History lists past and current sessions, newest first.
+// Query params: player, limit (default 100, max 1000).
+func (h *PlayerHandler) History(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if l := r.URL.Query().Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+		limit = min(n, 1000)
+	}
+	sessions, err := h.tracker.History(chi.URLParam(r, "id"), r.URL.Query().Get("player"), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query player history")
+		return
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/reedfamily/reedout/internal/docker"
+)
+
+// SecurityReport compares a server's configured security profile with what
+// Docker reports for its container.
+type SecurityReport struct {
+	ServerID     string                    `json:"server_id"`
+	Name         string                    `json:"name"`
+	Profile      *docker.SecurityProfile   `json:"profile"`
+	Effective    *docker.EffectiveSecurity `json:"effective"`
+	DataDirOwner string                    `json:"data_dir_owner,omitempty"`
+	Warnings     []string                  `json:"warnings"`
+}
+
+// Security returns the security report for a single server.
+func (h *ServerHandler) Security(w http.ResponseWriter, r *http.Request) {
+	s, err := h.getServer(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+	writeJSON(w, http.StatusOK, h.securityReport(ctx, s))
+}
+
+// SecurityReports returns the security report for every server.
+func (h *ServerHandler) SecurityReports(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Query(`SELECT ` + serverColumns + ` FROM servers ORDER BY created_at DESC`)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query servers")
+		return
+	}
+	defer rows.Close()
+
+	servers := []Server{}
+	for rows.Next() {
+		s, err := scanServer(rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan server")
+			return
+		}
+		servers = append(servers, s)
+	}
+	rows.Close()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+	reports := make([]SecurityReport, 0, len(servers))
+	for _, s := range servers {
+		reports = append(reports, h.securityReport(ctx, s))
+	}
+	writeJSON(w, http.StatusOK, reports)
+}
+
+func (h *ServerHandler) securityReport(ctx context.Context, s Server) SecurityReport {
+	report := SecurityReport{ServerID: s.ID, Name: s.Name, Profile: s.Security, Warnings: []string{}}
+	warn := func(format string, args ...any) {
+		report.Warnings = append(report.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	// Named volumes have no host path to check ownership on
+	if info, err := os.Stat(filepath.Join(h.dataDir, "servers", s.ID)); err == nil && !s.Storage.UsesVolume() {
+		if st, ok := info.Sys().(*syscall.Stat_t); ok {
+			report.DataDirOwner = fmt.Sprintf("%d:%d", st.Uid, st.Gid)
+		}
+	}
+
+	if s.ContainerID == "" {
+		warn("server has no container")
+		return report
+	}
+	eff, err := h.docker.InspectSecurity(ctx, s.ContainerID)
+	if err != nil {
+		warn("failed to inspect container: %v", err)
+		return report
+	}
+	report.Effective = eff
+
+	if eff.User == "" || eff.User == "0" || eff.User == "root" || eff.User == "0:0" {
+		warn("container runs as root")
+	}
+	if eff.Privileged {
+		warn("container is privileged")
+	}
+
+	p := s.Security
+	if p == nil {
+		warn("no security profile configured; container uses Docker defaults")
+		return report
+	}
+	// Drift between the profile and the container means it was created
+	// before the profile changed and needs recreating.
+	if p.User != "" && eff.User != p.User {
+		warn("profile user %s but container runs as %q", p.User, eff.User)
+	}
+	if p.CapDropAll && !slices.Contains(eff.CapDrop, "ALL") {
+		warn("profile drops all capabilities but container does not")
+	}
+	for _, c := range p.CapAdd {
+		if !slices.Contains(eff.CapAdd, c) {
+			warn("profile allows capability %s but container does not have it", c)
+		}
+	}
+	for _, c := range eff.CapAdd {
+		if !slices.Contains(p.CapAdd, c) {
+			warn("container has capability %s which is not in the profile allowlist", c)
+		}
+	}
+	if p.NoNewPrivileges && !eff.NoNewPrivileges {
+		warn("profile sets no-new-privileges but container does not")
+	}
+	if p.ReadOnlyRootfs && !eff.ReadOnlyRootfs {
+		warn("profile sets a read-only rootfs but container rootfs is writable")
+	}
+	for mnt := range p.Tmpfs {
+		if _, ok := eff.Tmpfs[mnt]; !ok {
+			warn("profile tmpfs mount %s is missing from container", mnt)
+		}
+	}
+	if p.Seccomp != "" && eff.Seccomp != "custom" {
+		warn("profile sets a custom seccomp profile but container uses %s", eff.Seccomp)
+	}
+	if p.User != "" && report.DataDirOwner != "" {
+		uid, gid, _ := docker.ParseUser(p.User)
+		if want := fmt.Sprintf("%d:%d", uid, gid); report.DataDirOwner != want {
+			warn("data directory is owned by %s, expected %s", report.DataDirOwner, want)
+		}
+	}
+	return report
+}
@@ -4,52 +4,84 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"maps"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/reedfamily/reedout/internal/configfile"
 	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/health"
+	"github.com/reedfamily/reedout/internal/install"
+	"github.com/reedfamily/reedout/internal/templates"
 )
 
 type ServerHandler struct {
-	db        *sql.DB
-	docker    *docker.Client
-	dataDir   string
-	templates []docker.GameTemplate
+	db         *sql.DB
+	docker     *docker.Client
+	dataDir    string
+	publicHost string
+	templates  *templates.Store
+	health     *health.Monitor
+	installs   *install.Service
+	configs    *configfile.Service
 }
 
 type Server struct {
-	ID          string            `json:"id"`
-	Name        string            `json:"name"`
-	Game        string            `json:"game"`
-	ContainerID string            `json:"container_id,omitempty"`
-	Image       string            `json:"image"`
-	Ports       []docker.PortMapping `json:"ports"`
-	Env         map[string]string `json:"env"`
-	Volumes     map[string]string `json:"volumes"`
-	MemoryLimit int64             `json:"memory_limit"`
-	CPULimit    float64           `json:"cpu_limit"`
-	Status      string            `json:"status"`
-	CreatedAt   string            `json:"created_at"`
-	UpdatedAt   string            `json:"updated_at"`
-}
-
-func NewServerHandler(db *sql.DB, dockerClient *docker.Client, dataDir string, templates []docker.GameTemplate) *ServerHandler {
+	ID            string                  `json:"id"`
+	Name          string                  `json:"name"`
+	Game          string                  `json:"game"`
+	TemplateID    string                  `json:"template_id"`
+	TemplateVer   int                     `json:"template_version"`
+	ContainerID   string                  `json:"container_id,omitempty"`
+	Image         string                  `json:"image"`
+	Ports         []docker.PortMapping    `json:"ports"`
+	Env           map[string]string       `json:"env"`
+	Config        map[string]string       `json:"config"` // config field values by env var or key
+	Volumes       map[string]string       `json:"volumes"`
+	MemoryLimit   int64                   `json:"memory_limit"`
+	CPULimit      float64                 `json:"cpu_limit"`
+	Security      *docker.SecurityProfile `json:"security,omitempty"`
+	Networks      []string                `json:"networks"`
+	Storage       docker.StorageConfig    `json:"storage"`
+	HealthCheck   *docker.HealthCheck     `json:"health_check,omitempty"`
+	Install       *docker.InstallConfig   `json:"install,omitempty"`
+	Status        string                  `json:"status"`
+	Health        string                  `json:"health,omitempty"` // starting, healthy, unhealthy
+	InstallStatus string                  `json:"install_status,omitempty"`
+	Address       string                  `json:"address,omitempty"`     // where players join, from the public game port
+	GameStatus    *game.Status            `json:"game_status,omitempty"` // last status query of a running server
+	Generated     map[string]string       `json:"-"`                     // generated placeholder values
+	CreatedAt     string                  `json:"created_at"`
+	UpdatedAt     string                  `json:"updated_at"`
+}
+
+func NewServerHandler(db *sql.DB, dockerClient *docker.Client, dataDir, publicHost string, templateStore *templates.Store, healthMonitor *health.Monitor, installSvc *install.Service, configSvc *configfile.Service) *ServerHandler {
 	return &ServerHandler{
-		db:        db,
-		docker:    dockerClient,
-		dataDir:   dataDir,
-		templates: templates,
+		db:         db,
+		docker:     dockerClient,
+		dataDir:    dataDir,
+		publicHost: publicHost,
+		templates:  templateStore,
+		health:     healthMonitor,
+		installs:   installSvc,
+		configs:    configSvc,
 	}
 }
 
 func (h *ServerHandler) List(w http.ResponseWriter, r *http.Request) {
-	rows, err := h.db.Query(`SELECT id, name, game, container_id, image, ports, env, volumes, memory_limit, cpu_limit, status, created_at, updated_at FROM servers ORDER BY created_at DESC`)
+	rows, err := h.db.Query(`SELECT ` + serverColumns + ` FROM servers ORDER BY created_at DESC`)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to query servers")
 		return
@@ -63,6 +95,7 @@ func (h *ServerHandler) List(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusInternalServerError, "failed to scan server")
 			return
 		}
+		s.Address = h.joinAddress(r, s)
 		servers = append(servers, s)
 	}
 
@@ -72,6 +105,7 @@ func (h *ServerHandler) List(w http.ResponseWriter, r *http.Request) {
 		type statusResult struct {
 			idx    int
 			status string
+			health string
 		}
 		ch := make(chan statusResult, len(servers))
 		statusCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
@@ -81,14 +115,14 @@ func (h *ServerHandler) List(w http.ResponseWriter, r *http.Request) {
 		for i, s := range servers {
 			if s.ContainerID != "" {
 				pending++
-				go func(idx int, containerID, serverID string) {
-					if status, err := h.docker.ContainerStatus(statusCtx, containerID); err == nil {
-						h.db.Exec("UPDATE servers SET status = ? WHERE id = ?", status, serverID)
-						ch <- statusResult{idx, status}
+				go func(idx int, s Server) {
+					if status, err := h.docker.ContainerStatus(statusCtx, s.ContainerID); err == nil {
+						h.db.Exec("UPDATE servers SET status = ? WHERE id = ?", status, s.ID)
+						ch <- statusResult{idx, status, h.health.Status(statusCtx, s.ID, s.ContainerID, s.HealthCheck)}
 					} else {
-						ch <- statusResult{idx, ""}
+						ch <- statusResult{idx, "", ""}
 					}
-				}(i, s.ContainerID, s.ID)
+				}(i, s)
 			}
 		}
 
@@ -96,6 +130,10 @@ func (h *ServerHandler) List(w http.ResponseWriter, r *http.Request) {
 			res := <-ch
 			if res.status != "" {
 				servers[res.idx].Status = res.status
+				servers[res.idx].Health = res.health
+			}
+			if res.status == "running" {
+				servers[res.idx].GameStatus = h.health.GameStatus(servers[res.idx].ID)
 			}
 		}
 	}
@@ -117,17 +155,30 @@ func (h *ServerHandler) Get(w http.ResponseWriter, r *http.Request) {
 			s.Status = status
 			h.db.Exec("UPDATE servers SET status = ? WHERE id = ?", status, s.ID)
 		}
+		s.Health = h.health.Status(ctx, s.ID, s.ContainerID, s.HealthCheck)
+		if s.Status == "running" {
+			s.GameStatus = h.health.GameStatus(s.ID)
+		}
 	}
+	if s.Install != nil {
+		if inst, err := h.installs.Latest(s.ID); err == nil && inst != nil {
+			s.InstallStatus = inst.Status
+		}
+	}
+	s.Address = h.joinAddress(r, s)
 	writeJSON(w, http.StatusOK, s)
 }
 
 func (h *ServerHandler) Create(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Name       string            `json:"name"`
-		TemplateID string            `json:"template_id"`
-		Env        map[string]string `json:"env"`
-		Memory     string            `json:"memory"`
-		CPU        float64           `json:"cpu"`
+		Name       string                `json:"name"`
+		TemplateID string                `json:"template_id"`
+		Env        map[string]string     `json:"env"`
+		Memory     string                `json:"memory"`
+		CPU        float64               `json:"cpu"`
+		Networks   []string              `json:"networks"`
+		Storage    *docker.StorageConfig `json:"storage"`
+		Image      string                `json:"image"` // one of the template's images
 	}
 	if err := decodeJSON(r, &req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body")
@@ -137,14 +188,15 @@ func (h *ServerHandler) Create(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "name and template_id required")
 		return
 	}
-
-	var tmpl *docker.GameTemplate
-	for _, t := range h.templates {
-		if t.ID == req.TemplateID {
-			tmpl = &t
-			break
-		}
+	if req.Networks == nil {
+		req.Networks = []string{}
+	}
+	if err := h.checkNetworks(req.Networks); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
+
+	tmpl := h.template(req.TemplateID)
 	if tmpl == nil {
 		writeError(w, http.StatusBadRequest, "template not found")
 		return
@@ -153,33 +205,54 @@ func (h *ServerHandler) Create(w http.ResponseWriter, r *http.Request) {
 	id := uuid.New().String()[:8]
 	containerName := fmt.Sprintf("reedout-%s-%s", tmpl.Game, id)
 
-	// Merge template env with overrides
-	env := make(map[string]string)
-	for k, v := range tmpl.Env {
-		env[k] = v
+	// Validate config values and fill in defaults
+	config, fieldErrs := docker.ResolveConfig(tmpl.ConfigFields, req.Env, nil)
+	if fieldErrs != nil {
+		writeFieldErrors(w, fieldErrs)
+		return
 	}
-	for k, v := range req.Env {
-		env[k] = v
+
+	image := tmpl.Image
+	if req.Image != "" && req.Image != tmpl.Image {
+		if !slices.Contains(slices.Collect(maps.Values(tmpl.Images)), req.Image) {
+			writeError(w, http.StatusBadRequest, "image is not offered by this template")
+			return
+		}
+		image = req.Image
 	}
 
-	// Create data directory for server volumes
-	serverDataDir := filepath.Join(h.dataDir, "servers", id)
-	if err := os.MkdirAll(serverDataDir, 0755); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to create data directory")
+	// Data lives in a host directory or, in volume mode, a named volume
+	storageCfg := tmpl.Storage
+	if req.Storage != nil {
+		// A volume driver and its options can mount any host path
+		if req.Storage.Driver != "" || len(req.Storage.DriverOpts) > 0 {
+			if user := currentUser(r); user == nil || !user.Admin {
+				writeError(w, http.StatusForbidden, "only admins can choose a volume driver")
+				return
+			}
+			storageCfg = *req.Storage
+		} else {
+			storageCfg.Mode = req.Storage.Mode
+		}
+	}
+	if err := storageCfg.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	dataSource := filepath.Join(h.dataDir, "servers", id)
+	if storageCfg.UsesVolume() {
+		dataSource = docker.ServerVolume(id)
+	}
 
-	// Resolve volumes (replace {data_dir} placeholder)
-	volumes := make(map[string]string)
-	for hostPath, containerPath := range tmpl.Volumes {
-		resolved := hostPath
-		if hostPath == "{data_dir}" {
-			resolved = serverDataDir
-		}
-		volumes[resolved] = containerPath
+	// Expand placeholders; generated values are kept with the server
+	vars := &templates.Vars{ServerID: id, ServerName: req.Name, DataDir: dataSource}
+	expanded, err := vars.Template(*tmpl)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
 	}
+	tmpl = &expanded
 
-	ports := docker.ParsePortMappings(tmpl.Ports)
 	memoryLimit := docker.ParseMemory(req.Memory)
 	if memoryLimit == 0 {
 		memoryLimit = docker.ParseMemory(tmpl.Memory)
@@ -188,45 +261,148 @@ func (h *ServerHandler) Create(w http.ResponseWriter, r *http.Request) {
 	if cpuLimit == 0 {
 		cpuLimit = tmpl.CPU
 	}
+	if err := vars.ExpandMap("config", config); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	env := templateEnv(tmpl, config, memoryLimit)
+	if err := vars.ExpandMap("env", env); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// undo removes what has been set up so far, newest first, when a later
+	// step fails
+	var cleanups []func()
+	undo := func() {
+		for i := len(cleanups) - 1; i >= 0; i-- {
+			cleanups[i]()
+		}
+	}
+
+	if storageCfg.UsesVolume() {
+		if err := h.docker.CreateVolume(r.Context(), dataSource, storageCfg); err != nil {
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create volume: %v", err))
+			return
+		}
+		cleanups = append(cleanups, func() { h.docker.RemoveVolume(context.Background(), dataSource) })
+	} else {
+		if err := os.MkdirAll(dataSource, 0755); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to create data directory")
+			return
+		}
+		cleanups = append(cleanups, func() { os.RemoveAll(dataSource) })
+	}
+	if tmpl.Security != nil && tmpl.Security.User != "" {
+		// The container user must own its data directory to write to it
+		uid, gid, _ := docker.ParseUser(tmpl.Security.User)
+		var err error
+		if storageCfg.UsesVolume() {
+			_, err = h.docker.RunHelper(r.Context(), dataSource, "chown", fmt.Sprintf("%d:%d", uid, gid), docker.HelperMount)
+		} else {
+			err = os.Chown(dataSource, uid, gid)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to chown %s to %s: %v", dataSource, tmpl.Security.User, err)
+		}
+	}
+
+	volumes := tmpl.Volumes
+	ports := tmpl.Ports
+	if err := h.makeDataDirs(r.Context(), Server{ID: id, Volumes: volumes, Security: tmpl.Security, Storage: storageCfg}); err != nil {
+		undo()
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create data directory: %v", err))
+		return
+	}
 
 	// Pull image
-	log.Printf("Pulling image %s...", tmpl.Image)
-	if err := h.docker.PullImage(r.Context(), tmpl.Image); err != nil {
+	log.Printf("Pulling image %s...", image)
+	if err := h.docker.PullImage(r.Context(), image); err != nil {
 		log.Printf("Warning: failed to pull image (may already exist locally): %v", err)
 	}
 
+	// Each server gets a private network; shared networks are joined on top of it
+	networks := []string{}
+	for _, n := range req.Networks {
+		networks = append(networks, docker.SharedNetwork(n))
+	}
+	for _, n := range append([]string{docker.ServerNetwork(id)}, networks...) {
+		if err := h.docker.EnsureNetwork(r.Context(), n, n != docker.ServerNetwork(id)); err != nil {
+			undo()
+			writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create network: %v", err))
+			return
+		}
+		// Shared networks outlive the server
+		if n == docker.ServerNetwork(id) {
+			cleanups = append(cleanups, func() { h.docker.RemoveNetwork(context.Background(), n) })
+		}
+	}
+
 	// Create container
 	containerID, err := h.docker.CreateContainer(r.Context(), docker.ContainerConfig{
 		Name:        containerName,
-		Image:       tmpl.Image,
+		Image:       image,
 		Env:         env,
 		Ports:       ports,
 		Volumes:     volumes,
 		MemoryLimit: memoryLimit,
 		CPULimit:    cpuLimit,
+		Security:    tmpl.Security,
+		HealthCheck: tmpl.HealthCheck,
+		Network:     docker.ServerNetwork(id),
+		Networks:    networks,
+		Aliases:     serverAliases(id, req.Name),
 	})
 	if err != nil {
+		undo()
 		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to create container: %v", err))
 		return
 	}
+	cleanups = append(cleanups, func() { h.docker.RemoveContainer(context.Background(), containerID) })
 
 	// Save to database
 	portsJSON, _ := json.Marshal(ports)
 	envJSON, _ := json.Marshal(env)
+	configJSON, _ := json.Marshal(config)
 	volumesJSON, _ := json.Marshal(volumes)
-
-	_, err = h.db.Exec(`INSERT INTO servers (id, name, game, container_id, image, ports, env, volumes, memory_limit, cpu_limit, status)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		id, req.Name, tmpl.Game, containerID, tmpl.Image,
-		string(portsJSON), string(envJSON), string(volumesJSON),
-		memoryLimit, cpuLimit, "created",
+	networksJSON, _ := json.Marshal(req.Networks)
+	storageJSON, _ := json.Marshal(storageCfg)
+	generatedJSON, _ := json.Marshal(vars.Generated)
+	securityJSON := ""
+	if tmpl.Security != nil {
+		b, _ := json.Marshal(tmpl.Security)
+		securityJSON = string(b)
+	}
+	healthJSON := ""
+	if tmpl.HealthCheck != nil {
+		b, _ := json.Marshal(tmpl.HealthCheck)
+		healthJSON = string(b)
+	}
+	installJSON := ""
+	if tmpl.Install != nil {
+		b, _ := json.Marshal(tmpl.Install)
+		installJSON = string(b)
+	}
+
+	_, err = h.db.Exec(`INSERT INTO servers (id, name, game, template_id, template_version, container_id, image, ports, env, config, volumes, memory_limit, cpu_limit, security, networks, storage, health_check, install, generated, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		id, req.Name, tmpl.Game, tmpl.ID, tmpl.Version, containerID, image,
+		string(portsJSON), string(envJSON), string(configJSON), string(volumesJSON),
+		memoryLimit, cpuLimit, securityJSON, string(networksJSON), string(storageJSON), healthJSON, installJSON, string(generatedJSON), "created",
 	)
 	if err != nil {
-		h.docker.RemoveContainer(context.Background(), containerID)
+		undo()
 		writeError(w, http.StatusInternalServerError, "failed to save server")
 		return
 	}
 
+	// Run the install script now so the server is ready by its first start
+	if tmpl.Install != nil {
+		if _, err := h.installs.Run(id); err != nil {
+			log.Printf("Warning: failed to start install for %s: %v", id, err)
+		}
+	}
+
 	s, _ := h.getServer(id)
 	writeJSON(w, http.StatusCreated, s)
 }
@@ -234,21 +410,289 @@ func (h *ServerHandler) Create(w http.ResponseWriter, r *http.Request) {
 func (h *ServerHandler) Update(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	var req struct {
-		Name string `json:"name"`
+		Name string            `json:"name"`
+		Env  map[string]string `json:"env"`
 	}
 	if err := decodeJSON(r, &req); err != nil {
 		writeError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
-	_, err := h.db.Exec("UPDATE servers SET name = ?, updated_at = ? WHERE id = ?", req.Name, time.Now(), id)
+	s, err := h.getServer(id)
 	if err != nil {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+
+	if req.Env != nil {
+		if s, err = h.changeConfig(r.Context(), s, req.Env); err != nil {
+			writeConfigError(w, err)
+			return
+		}
+	}
+	renamed := req.Name != "" && req.Name != s.Name
+	if req.Name != "" {
+		s.Name = req.Name
+	}
+	if err := h.saveConfig(s); err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to update server")
 		return
 	}
-	s, _ := h.getServer(id)
+	if renamed {
+		if err := h.refreshAliases(r.Context(), s); err != nil {
+			log.Printf("Warning: failed to update network aliases of %s: %v", id, err)
+		}
+	}
+	s, _ = h.getServer(id)
 	writeJSON(w, http.StatusOK, s)
 }
 
+// configError is a config change that can't be applied, with the status to
+// answer it with.
+type configError struct {
+	status  int
+	message string
+	fields  map[string]string
+}
+
+func (e *configError) Error() string { return e.message }
+
+func writeConfigError(w http.ResponseWriter, err error) {
+	var ce *configError
+	switch {
+	case errors.As(err, &ce) && ce.fields != nil:
+		writeFieldErrors(w, ce.fields)
+	case errors.As(err, &ce):
+		writeError(w, ce.status, ce.message)
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// changeConfig applies config values, by env var or key, on top of a
+// server's current ones. Config changes are validated against the template.
+// Env changes need a new container, since Docker can't change the env of an
+// existing one; file-backed values are written on the next start. The
+// result still has to be saved.
+func (h *ServerHandler) changeConfig(ctx context.Context, s Server, values map[string]string) (Server, error) {
+	tmpl := h.serverTemplate(s)
+	if tmpl == nil {
+		return s, &configError{status: http.StatusBadRequest, message: "server template no longer exists"}
+	}
+	config, fieldErrs := docker.ResolveConfig(tmpl.ConfigFields, values, configValues(s))
+	if fieldErrs != nil {
+		return s, &configError{status: http.StatusBadRequest, message: "invalid config", fields: fieldErrs}
+	}
+
+	next := s
+	vars := &templates.Vars{ServerID: s.ID, ServerName: s.Name, DataDir: h.dataSource(s), Generated: maps.Clone(s.Generated)}
+	if err := vars.ExpandMap("config", config); err != nil {
+		return s, &configError{status: http.StatusBadRequest, message: err.Error()}
+	}
+	next.Config = config
+	next.Env = maps.Clone(s.Env)
+	applyConfig(next.Env, tmpl.ConfigFields, config)
+	next.Generated = vars.Generated
+
+	if maps.Equal(next.Env, s.Env) {
+		return next, nil
+	}
+	if s.ContainerID != "" {
+		if status, err := h.docker.ContainerStatus(ctx, s.ContainerID); err == nil && status == "running" {
+			return s, &configError{status: http.StatusConflict, message: "stop the server before changing its config"}
+		}
+	}
+	if err := h.recreateContainer(ctx, &s, next); err != nil {
+		return s, fmt.Errorf("failed to recreate container: %w", err)
+	}
+	return s, nil
+}
+
+// saveConfig stores a server's name, env and config.
+func (h *ServerHandler) saveConfig(s Server) error {
+	envJSON, _ := json.Marshal(s.Env)
+	configJSON, _ := json.Marshal(s.Config)
+	generatedJSON, _ := json.Marshal(s.Generated)
+	_, err := h.db.Exec("UPDATE servers SET name = ?, env = ?, config = ?, generated = ?, container_id = ?, updated_at = ? WHERE id = ?",
+		s.Name, string(envJSON), string(configJSON), string(generatedJSON), s.ContainerID, time.Now(), s.ID)
+	return err
+}
+
+// recreateContainer replaces a stopped server's container with one built
+// from next. On success s is updated to next; on failure the old container
+// is restored.
+func (h *ServerHandler) recreateContainer(ctx context.Context, s *Server, next Server) error {
+	if err := h.makeDataDirs(ctx, next); err != nil {
+		return err
+	}
+	if s.ContainerID != "" {
+		if err := h.docker.RemoveContainer(ctx, s.ContainerID); err != nil {
+			return err
+		}
+	}
+	containerID, err := h.docker.CreateContainer(ctx, h.containerConfig(next))
+	if err != nil {
+		// Put the previous container back so the server stays usable
+		if oldID, restoreErr := h.docker.CreateContainer(ctx, h.containerConfig(*s)); restoreErr == nil {
+			s.ContainerID = oldID
+		} else {
+			s.ContainerID = ""
+		}
+		h.db.Exec("UPDATE servers SET container_id = ? WHERE id = ?", s.ContainerID, s.ID)
+		return err
+	}
+	next.ContainerID = containerID
+	*s = next
+	return nil
+}
+
+// joinAddress returns the address players connect to: the public game port
+// on the configured public host, or on the host the panel was reached at.
+func (h *ServerHandler) joinAddress(r *http.Request, s Server) string {
+	p := docker.FindPort(s.Ports, docker.PortGame)
+	if p == nil || p.Internal || p.Host == "" {
+		return ""
+	}
+	host := h.publicHost
+	if host == "" {
+		host = r.Host
+		if hostname, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = hostname
+		}
+	}
+	return net.JoinHostPort(host, docker.FirstPort(p.Host))
+}
+
+// containerConfig rebuilds the container settings of an existing server.
+func (h *ServerHandler) containerConfig(s Server) docker.ContainerConfig {
+	networks := []string{}
+	for _, n := range s.Networks {
+		networks = append(networks, docker.SharedNetwork(n))
+	}
+	return docker.ContainerConfig{
+		Name:        fmt.Sprintf("reedout-%s-%s", s.Game, s.ID),
+		Image:       s.Image,
+		Env:         s.Env,
+		Ports:       s.Ports,
+		Volumes:     s.Volumes,
+		MemoryLimit: s.MemoryLimit,
+		CPULimit:    s.CPULimit,
+		Security:    s.Security,
+		HealthCheck: s.HealthCheck,
+		Network:     docker.ServerNetwork(s.ID),
+		Networks:    networks,
+		Aliases:     serverAliases(s.ID, s.Name),
+	}
+}
+
+// dataSource returns the host directory or volume holding a server's data.
+func (h *ServerHandler) dataSource(s Server) string {
+	if s.Storage.UsesVolume() {
+		return docker.ServerVolume(s.ID)
+	}
+	return filepath.Join(h.dataDir, "servers", s.ID)
+}
+
+// makeDataDirs creates the subdirectories of a server's data that its
+// volumes mount, e.g. "{data_dir}/config", owned by the container user.
+// Docker refuses to mount sources that don't exist.
+func (h *ServerHandler) makeDataDirs(ctx context.Context, s Server) error {
+	root := h.dataSource(s)
+	uid, gid := -1, -1
+	if s.Security != nil && s.Security.User != "" {
+		uid, gid, _ = docker.ParseUser(s.Security.User)
+	}
+	for source := range s.Volumes {
+		sub, ok := strings.CutPrefix(source, root+"/")
+		if !ok || sub == "" {
+			continue
+		}
+		if s.Storage.UsesVolume() {
+			dir := docker.HelperMount + "/" + sub
+			if _, err := h.docker.RunHelper(ctx, root, "mkdir", "-p", dir); err != nil {
+				return fmt.Errorf("create %s: %w", sub, err)
+			}
+			if uid >= 0 {
+				if _, err := h.docker.RunHelper(ctx, root, "chown", fmt.Sprintf("%d:%d", uid, gid), dir); err != nil {
+					return fmt.Errorf("chown %s: %w", sub, err)
+				}
+			}
+			continue
+		}
+		dir := filepath.Join(root, filepath.FromSlash(sub))
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("create %s: %w", sub, err)
+		}
+		if uid >= 0 {
+			if err := os.Chown(dir, uid, gid); err != nil {
+				return fmt.Errorf("chown %s: %w", sub, err)
+			}
+		}
+	}
+	return nil
+}
+
+// templateEnv builds a server's env from its template and resolved config.
+func templateEnv(tmpl *docker.GameTemplate, config map[string]string, memoryLimit int64) map[string]string {
+	env := make(map[string]string)
+	for k, v := range tmpl.Env {
+		env[k] = v
+	}
+	applyConfig(env, tmpl.ConfigFields, config)
+
+	// Images built for Pterodactyl eggs read their command and memory from env
+	if tmpl.Startup != "" {
+		env["STARTUP"] = tmpl.Startup
+		env["SERVER_MEMORY"] = strconv.FormatInt(memoryLimit/(1024*1024), 10)
+	}
+	return env
+}
+
+// template looks up the latest version of a template.
+func (h *ServerHandler) template(id string) *docker.GameTemplate {
+	t, err := h.templates.Get(id)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// serverTemplate returns the template version a server was created from.
+func (h *ServerHandler) serverTemplate(s Server) *docker.GameTemplate {
+	if s.TemplateVer == 0 {
+		return h.template(s.TemplateID)
+	}
+	t, err := h.templates.GetVersion(s.TemplateID, s.TemplateVer)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+// configValues returns a server's current config values. Servers created
+// before config values were stored fall back to their env.
+func configValues(s Server) map[string]string {
+	values := maps.Clone(s.Env)
+	maps.Copy(values, s.Config)
+	return values
+}
+
+// applyConfig writes resolved config values into a container env.
+func applyConfig(env map[string]string, fields []docker.ConfigField, config map[string]string) {
+	for _, f := range fields {
+		if f.EnvVar != "" {
+			env[f.EnvVar] = config[f.Name()]
+		}
+	}
+}
+
+// writeFieldErrors reports per-field config validation errors.
+func writeFieldErrors(w http.ResponseWriter, errs map[string]string) {
+	writeJSON(w, http.StatusBadRequest, map[string]any{
+		"error":  "invalid config",
+		"fields": errs,
+	})
+}
+
 func (h *ServerHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	s, err := h.getServer(id)
@@ -260,10 +704,14 @@ func (h *ServerHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	if s.ContainerID != "" {
 		h.docker.RemoveContainer(r.Context(), s.ContainerID)
 	}
+	h.docker.RemoveNetwork(r.Context(), docker.ServerNetwork(id))
 
-	// Remove server data directory
-	serverDataDir := filepath.Join(h.dataDir, "servers", id)
-	os.RemoveAll(serverDataDir)
+	// Remove server data
+	if s.Storage.UsesVolume() {
+		h.docker.RemoveVolume(r.Context(), docker.ServerVolume(id))
+	} else {
+		os.RemoveAll(filepath.Join(h.dataDir, "servers", id))
+	}
 
 	h.db.Exec("DELETE FROM servers WHERE id = ?", id)
 	writeJSON(w, http.StatusOK, map[string]string{"message": "server deleted"})
@@ -276,12 +724,19 @@ func (h *ServerHandler) Start(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, "server not found")
 		return
 	}
+	if !h.checkInstalled(w, s) {
+		return
+	}
+	if err := h.configs.Render(r.Context(), id); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to write config files: %v", err))
+		return
+	}
 	if err := h.docker.StartContainer(r.Context(), s.ContainerID); err != nil {
 		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to start: %v", err))
 		return
 	}
 	h.db.Exec("UPDATE servers SET status = 'running', updated_at = ? WHERE id = ?", time.Now(), id)
-	writeJSON(w, http.StatusOK, map[string]string{"status": "running"})
+	h.respondRunning(w, r, s)
 }
 
 func (h *ServerHandler) Stop(w http.ResponseWriter, r *http.Request) {
@@ -306,20 +761,85 @@ func (h *ServerHandler) Restart(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusNotFound, "server not found")
 		return
 	}
+	if !h.checkInstalled(w, s) {
+		return
+	}
+	if err := h.configs.Render(r.Context(), id); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to write config files: %v", err))
+		return
+	}
 	if err := h.docker.RestartContainer(r.Context(), s.ContainerID); err != nil {
 		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to restart: %v", err))
 		return
 	}
 	h.db.Exec("UPDATE servers SET status = 'running', updated_at = ? WHERE id = ?", time.Now(), id)
-	writeJSON(w, http.StatusOK, map[string]string{"status": "running"})
+	h.respondRunning(w, r, s)
 }
 
-func (h *ServerHandler) Templates(w http.ResponseWriter, r *http.Request) {
-	writeJSON(w, http.StatusOK, h.templates)
+// checkInstalled refuses to start a server whose install script is still
+// running or failed. It writes the error response and returns false.
+func (h *ServerHandler) checkInstalled(w http.ResponseWriter, s Server) bool {
+	if s.Install == nil {
+		return true
+	}
+	if err := h.installs.Ready(s.ID); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return false
+	}
+	return true
 }
 
+// respondRunning answers a start or restart. With ?wait=healthy it first
+// blocks until the server's health check passes, fails, or ?timeout expires.
+func (h *ServerHandler) respondRunning(w http.ResponseWriter, r *http.Request, s Server) {
+	if r.URL.Query().Get("wait") != "healthy" {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "running"})
+		return
+	}
+	if s.HealthCheck == nil {
+		writeError(w, http.StatusBadRequest, "server has no health check to wait for")
+		return
+	}
+
+	timeout := 2 * time.Minute
+	if t := r.URL.Query().Get("timeout"); t != "" {
+		d, err := time.ParseDuration(t)
+		if err != nil || d <= 0 || d > 15*time.Minute {
+			writeError(w, http.StatusBadRequest, "invalid timeout: use a duration up to 15m")
+			return
+		}
+		timeout = d
+	}
+	// The server's write timeout is much shorter than a server takes to boot
+	http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 10*time.Second))
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		switch h.health.Status(ctx, s.ID, s.ContainerID, s.HealthCheck) {
+		case health.Healthy:
+			writeJSON(w, http.StatusOK, map[string]string{"status": "running", "health": health.Healthy})
+			return
+		case health.Unhealthy:
+			writeError(w, http.StatusServiceUnavailable, "server is running but unhealthy")
+			return
+		}
+		select {
+		case <-ctx.Done():
+			writeError(w, http.StatusGatewayTimeout, "timed out waiting for server to become healthy")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// serverColumns lists the columns read by scanServerFields, in order.
+const serverColumns = `id, name, game, template_id, template_version, container_id, image, ports, env, config, volumes, memory_limit, cpu_limit, security, networks, storage, health_check, install, generated, status, created_at, updated_at`
+
 func (h *ServerHandler) getServer(id string) (Server, error) {
-	row := h.db.QueryRow(`SELECT id, name, game, container_id, image, ports, env, volumes, memory_limit, cpu_limit, status, created_at, updated_at FROM servers WHERE id = ?`, id)
+	row := h.db.QueryRow(`SELECT `+serverColumns+` FROM servers WHERE id = ?`, id)
 	return scanServerRow(row)
 }
 
@@ -328,49 +848,57 @@ type scanner interface {
 }
 
 func scanServerRow(row *sql.Row) (Server, error) {
-	var s Server
-	var portsJSON, envJSON, volumesJSON string
-	var containerID sql.NullString
-	err := row.Scan(&s.ID, &s.Name, &s.Game, &containerID, &s.Image, &portsJSON, &envJSON, &volumesJSON, &s.MemoryLimit, &s.CPULimit, &s.Status, &s.CreatedAt, &s.UpdatedAt)
-	if err != nil {
-		return s, err
-	}
-	s.ContainerID = containerID.String
-	json.Unmarshal([]byte(portsJSON), &s.Ports)
-	json.Unmarshal([]byte(envJSON), &s.Env)
-	json.Unmarshal([]byte(volumesJSON), &s.Volumes)
-	if s.Ports == nil {
-		s.Ports = []docker.PortMapping{}
-	}
-	if s.Env == nil {
-		s.Env = map[string]string{}
-	}
-	if s.Volumes == nil {
-		s.Volumes = map[string]string{}
-	}
-	return s, nil
+	return scanServerFields(row)
 }
 
 func scanServer(rows *sql.Rows) (Server, error) {
+	return scanServerFields(rows)
+}
+
+func scanServerFields(sc scanner) (Server, error) {
 	var s Server
-	var portsJSON, envJSON, volumesJSON string
+	var portsJSON, envJSON, configJSON, volumesJSON, securityJSON, networksJSON, storageJSON, healthJSON, installJSON, generatedJSON string
 	var containerID sql.NullString
-	err := rows.Scan(&s.ID, &s.Name, &s.Game, &containerID, &s.Image, &portsJSON, &envJSON, &volumesJSON, &s.MemoryLimit, &s.CPULimit, &s.Status, &s.CreatedAt, &s.UpdatedAt)
+	err := sc.Scan(&s.ID, &s.Name, &s.Game, &s.TemplateID, &s.TemplateVer, &containerID, &s.Image, &portsJSON, &envJSON, &configJSON, &volumesJSON, &s.MemoryLimit, &s.CPULimit, &securityJSON, &networksJSON, &storageJSON, &healthJSON, &installJSON, &generatedJSON, &s.Status, &s.CreatedAt, &s.UpdatedAt)
 	if err != nil {
 		return s, err
 	}
 	s.ContainerID = containerID.String
 	json.Unmarshal([]byte(portsJSON), &s.Ports)
 	json.Unmarshal([]byte(envJSON), &s.Env)
+	json.Unmarshal([]byte(configJSON), &s.Config)
 	json.Unmarshal([]byte(volumesJSON), &s.Volumes)
+	json.Unmarshal([]byte(networksJSON), &s.Networks)
+	json.Unmarshal([]byte(storageJSON), &s.Storage)
+	json.Unmarshal([]byte(generatedJSON), &s.Generated)
+	if installJSON != "" {
+		s.Install = &docker.InstallConfig{}
+		json.Unmarshal([]byte(installJSON), s.Install)
+	}
+	if healthJSON != "" {
+		s.HealthCheck = &docker.HealthCheck{}
+		json.Unmarshal([]byte(healthJSON), s.HealthCheck)
+	}
+	if securityJSON != "" {
+		s.Security = &docker.SecurityProfile{}
+		json.Unmarshal([]byte(securityJSON), s.Security)
+	}
 	if s.Ports == nil {
 		s.Ports = []docker.PortMapping{}
 	}
+	// Servers created before ports had names get the default ones
+	docker.NamePorts(s.Ports)
 	if s.Env == nil {
 		s.Env = map[string]string{}
 	}
+	if s.Config == nil {
+		s.Config = map[string]string{}
+	}
 	if s.Volumes == nil {
 		s.Volumes = map[string]string{}
 	}
+	if s.Networks == nil {
+		s.Networks = []string{}
+	}
 	return s, nil
 }
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+	"github.com/reedfamily/reedout/internal/audit"
+	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/templates"
+)
+
+const (
+	shellPingInterval = 25 * time.Second
+	shellReadTimeout  = 60 * time.Second
+)
+
+type ShellHandler struct {
+	db        *sql.DB
+	docker    *docker.Client
+	audit     *audit.Service
+	templates *templates.Store
+}
+
+// shellMessage is sent by the client. Input carries keystrokes, resize the
+// terminal size, and ping is an application-level keepalive.
+type shellMessage struct {
+	Type string `json:"type"` // input, resize, ping
+	Data string `json:"data,omitempty"`
+	Cols uint   `json:"cols,omitempty"`
+	Rows uint   `json:"rows,omitempty"`
+}
+
+func NewShellHandler(db *sql.DB, dockerClient *docker.Client, auditSvc *audit.Service, templateStore *templates.Store) *ShellHandler {
+	return &ShellHandler{db: db, docker: dockerClient, audit: auditSvc, templates: templateStore}
+}
+
+// Handle opens an interactive TTY shell in a server's container and proxies
+// it over a WebSocket. Output is sent as binary messages. Admin only.
+func (h *ShellHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	user := currentUser(r)
+
+	var containerID, templateID string
+	err := h.db.QueryRow("SELECT container_id, template_id FROM servers WHERE id = ?", id).Scan(&containerID, &templateID)
+	if err != nil || containerID == "" {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	status, err := h.docker.ContainerStatus(r.Context(), containerID)
+	if err != nil || status != "running" {
+		writeError(w, http.StatusConflict, "server is not running")
+		return
+	}
+
+	shell := "/bin/sh"
+	if t, err := h.templates.Get(templateID); err == nil && t.Shell != "" {
+		shell = t.Shell
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("shell websocket upgrade error: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// The hijacked request context ends with the handler; docker calls made
+	// during cleanup need their own.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Print the shell's PID first so the session can be killed on disconnect:
+	// closing the attach stream doesn't end a TTY exec.
+	execID, attach, err := h.docker.ContainerExecAttach(ctx, containerID,
+		[]string{"/bin/sh", "-c", `echo "$$"; exec "$0"`, shell})
+	if err != nil {
+		conn.WriteMessage(websocket.TextMessage, []byte("Error: "+err.Error()))
+		return
+	}
+	defer attach.Close()
+
+	pidLine, err := attach.Reader.ReadString('\n')
+	if err != nil {
+		conn.WriteMessage(websocket.TextMessage, []byte("Error: failed to start shell"))
+		return
+	}
+	pid := strings.TrimSpace(pidLine)
+
+	auditID, err := h.audit.Record(user.ID, user.Username, "shell", id, shell)
+	if err != nil {
+		log.Printf("shell: audit record: %v", err)
+	}
+	started := time.Now()
+	defer func() {
+		h.audit.Finish(auditID, shell+" ("+time.Since(started).Round(time.Second).String()+")")
+		h.killShell(containerID, execID, pid)
+	}()
+
+	var writeMu sync.Mutex
+	write := func(msgType int, data []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		return conn.WriteMessage(msgType, data)
+	}
+
+	// Container output -> WebSocket. The session ends when the shell exits.
+	outputDone := make(chan struct{})
+	go func() {
+		defer close(outputDone)
+		buf := make([]byte, 4096)
+		for {
+			n, err := attach.Reader.Read(buf)
+			if n > 0 {
+				if write(websocket.BinaryMessage, buf[:n]) != nil {
+					return
+				}
+			}
+			if err != nil {
+				write(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "shell exited"))
+				conn.Close()
+				return
+			}
+		}
+	}()
+
+	// Keepalive: ping the client and drop the session if it stops answering
+	conn.SetReadDeadline(time.Now().Add(shellReadTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(shellReadTimeout))
+	})
+	go func() {
+		ticker := time.NewTicker(shellPingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-outputDone:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(5*time.Second)) != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	// WebSocket -> container stdin
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(shellReadTimeout))
+
+		var msg shellMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case "input":
+			if _, err := attach.Conn.Write([]byte(msg.Data)); err != nil {
+				return
+			}
+		case "resize":
+			if msg.Cols > 0 && msg.Rows > 0 {
+				h.docker.ExecResize(ctx, execID, msg.Cols, msg.Rows)
+			}
+		case "ping":
+			write(websocket.TextMessage, []byte(`{"type":"pong"}`))
+		}
+	}
+}
+
+// killShell ends a shell session that is still running after the client left.
+func (h *ShellHandler) killShell(containerID, execID, pid string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if running, err := h.docker.ExecRunning(ctx, execID); err != nil || !running {
+		return
+	}
+	if pid == "" {
+		log.Printf("shell: exec %s still running and its pid is unknown", execID)
+		return
+	}
+	for _, sig := range []string{"-HUP", "-KILL"} {
+		h.docker.Exec(ctx, containerID, []string{"kill", sig, pid}, 1024)
+		time.Sleep(time.Second)
+		if running, err := h.docker.ExecRunning(ctx, execID); err != nil || !running {
+			return
+		}
+	}
+	log.Printf("shell: failed to stop exec %s (pid %s)", execID, pid)
+}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/egg"
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/game/pattern"
+	"github.com/reedfamily/reedout/internal/templates"
+)
+
+// maxEggSize caps uploaded egg files.
+const maxEggSize = 5 << 20
+
+type TemplateHandler struct {
+	store *templates.Store
+}
+
+func NewTemplateHandler(store *templates.Store) *TemplateHandler {
+	return &TemplateHandler{store: store}
+}
+
+func (h *TemplateHandler) List(w http.ResponseWriter, r *http.Request) {
+	list, err := h.store.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query templates")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (h *TemplateHandler) Get(w http.ResponseWriter, r *http.Request) {
+	t, err := h.store.Get(chi.URLParam(r, "id"))
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+// Definition returns the template as saved, without the template it extends
+// merged in. This is what Update expects back.
+func (h *TemplateHandler) Definition(w http.ResponseWriter, r *http.Request) {
+	t, err := h.store.Definition(chi.URLParam(r, "id"))
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (h *TemplateHandler) Versions(w http.ResponseWriter, r *http.Request) {
+	versions, err := h.store.Versions(chi.URLParam(r, "id"))
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, versions)
+}
+
+func (h *TemplateHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(chi.URLParam(r, "version"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid version")
+		return
+	}
+	t, err := h.store.GetVersion(chi.URLParam(r, "id"), version)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (h *TemplateHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var t docker.GameTemplate
+	if err := decodeJSON(r, &t); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	created, err := h.store.Create(t)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// Update saves the request body as a new version of the template.
+func (h *TemplateHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var t docker.GameTemplate
+	if err := decodeJSON(r, &t); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	updated, err := h.store.Update(chi.URLParam(r, "id"), t)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+func (h *TemplateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.store.Delete(chi.URLParam(r, "id")); err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "template deleted"})
+}
+
+// Import converts a Pterodactyl egg in the request body into a template.
+// Query params: id, game, port, protocol, memory. With save=true the
+// template is added to the store.
+func (h *TemplateHandler) Import(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxEggSize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read egg")
+		return
+	}
+
+	q := r.URL.Query()
+	opts := egg.Options{
+		ID:       q.Get("id"),
+		Game:     q.Get("game"),
+		Protocol: q.Get("protocol"),
+		Memory:   q.Get("memory"),
+	}
+	if p := q.Get("port"); p != "" {
+		if opts.Port, err = strconv.Atoi(p); err != nil || opts.Port < 1 || opts.Port > 65535 {
+			writeError(w, http.StatusBadRequest, "invalid port")
+			return
+		}
+	}
+
+	res, err := egg.Convert(data, opts)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := res.Template.Validate(); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, "converted template is invalid: "+err.Error())
+		return
+	}
+
+	if q.Get("save") != "true" {
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+	created, err := h.store.Create(res.Template)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+	res.Template = *created
+	writeJSON(w, http.StatusCreated, res)
+}
+
+// maxTestLines caps the sample log lines of an adapter test.
+const maxTestLines = 1000
+
+// TestAdapter runs sample log lines through an adapter and returns the
+// event each line produces, or null. Body: {"adapter", "lines"} to try a
+// declaration before saving it, or {"template", "lines"} to use the
+// adapter of a stored template's game.
+func (h *TemplateHandler) TestAdapter(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Adapter  *docker.AdapterSpec `json:"adapter"`
+		Template string              `json:"template"`
+		Lines    []string            `json:"lines"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if len(req.Lines) > maxTestLines {
+		writeError(w, http.StatusBadRequest, "too many lines")
+		return
+	}
+
+	var adapter game.GameAdapter
+	switch {
+	case req.Adapter != nil:
+		a, err := pattern.New("test", *req.Adapter)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid adapter: "+err.Error())
+			return
+		}
+		adapter = a
+	case req.Template != "":
+		t, err := h.store.Get(req.Template)
+		if err != nil {
+			writeTemplateError(w, err)
+			return
+		}
+		if adapter = game.Get(t.Game); adapter == nil {
+			writeError(w, http.StatusBadRequest, "game "+t.Game+" has no adapter")
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "adapter or template is required")
+		return
+	}
+
+	type result struct {
+		Line  string         `json:"line"`
+		Event *game.LogEvent `json:"event"`
+	}
+	// The lines are parsed as one log, so a leave can name the player that joined
+	parse := game.LogParser(adapter)
+	results := make([]result, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		results = append(results, result{Line: line, Event: parse(line)})
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+// writeTemplateError maps template store errors to HTTP responses.
+func writeTemplateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, templates.ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, templates.ErrExists), errors.Is(err, templates.ErrInUse), errors.Is(err, templates.ErrExtended):
+		writeError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, templates.ErrInvalid):
+		writeError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "template store error")
+	}
+}
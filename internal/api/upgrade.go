@@ -0,0 +1,204 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/templates"
+)
+
+// UpgradePlan previews moving a server to the latest version of its template.
+type UpgradePlan struct {
+	TemplateID  string             `json:"template_id"`
+	FromVersion int                `json:"from_version"`
+	ToVersion   int                `json:"to_version"`
+	Template    []templates.Change `json:"template"` // template changes between the versions
+	Server      []templates.Change `json:"server"`   // resulting changes to the server
+	Errors      map[string]string  `json:"errors,omitempty"`
+	Reinstall   bool               `json:"reinstall_recommended"`
+}
+
+// upgradeTarget is the server's state after an upgrade, plus its plan.
+type upgradeTarget struct {
+	plan UpgradePlan
+	next Server
+}
+
+// planUpgrade works out what upgrading a server to the latest template
+// version would change. env overrides config values, e.g. to fix values the
+// new version rejects.
+func (h *ServerHandler) planUpgrade(s Server, env map[string]string) (*upgradeTarget, error) {
+	latest, err := h.templates.Get(s.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	current := h.serverTemplate(s)
+	if current == nil {
+		// The old version is gone; compare against an empty template
+		current = &docker.GameTemplate{ID: s.TemplateID}
+	}
+
+	plan := UpgradePlan{
+		TemplateID:  s.TemplateID,
+		FromVersion: s.TemplateVer,
+		ToVersion:   latest.Version,
+		Template:    templates.Diff(current, latest),
+		Server:      []templates.Change{},
+	}
+
+	// Keep the server's config values where the fields still exist
+	config, fieldErrs := docker.ResolveConfig(latest.ConfigFields, env, configValues(s))
+	plan.Errors = fieldErrs
+
+	// Values generated for the old version are reused where they still appear
+	vars := &templates.Vars{ServerID: s.ID, ServerName: s.Name, DataDir: h.dataSource(s), Generated: maps.Clone(s.Generated)}
+	expanded, err := vars.Template(*latest)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", templates.ErrInvalid, err)
+	}
+	latest = &expanded
+	if err := vars.ExpandMap("config", config); err != nil {
+		return nil, fmt.Errorf("%w: %v", templates.ErrInvalid, err)
+	}
+
+	next := s
+	next.TemplateVer = latest.Version
+	next.Image = latest.Image
+	if slices.Contains(slices.Collect(maps.Values(latest.Images)), s.Image) {
+		next.Image = s.Image
+	}
+	next.Config = config
+	next.Env = templateEnv(latest, config, s.MemoryLimit)
+	if err := vars.ExpandMap("env", next.Env); err != nil {
+		return nil, fmt.Errorf("%w: %v", templates.ErrInvalid, err)
+	}
+	next.Generated = vars.Generated
+	next.Ports = latest.Ports
+	next.Volumes = latest.Volumes
+	next.Security = latest.Security
+	next.HealthCheck = latest.HealthCheck
+	next.Install = latest.Install
+
+	if fieldErrs == nil {
+		plan.Server = templates.DiffJSON(upgradeView(s), upgradeView(next))
+	}
+	plan.Reinstall = latest.Install != nil && !reflect.DeepEqual(s.Install, latest.Install)
+	return &upgradeTarget{plan: plan, next: next}, nil
+}
+
+// upgradeView is the part of a server an upgrade can change.
+func upgradeView(s Server) map[string]any {
+	return map[string]any{
+		"image":        s.Image,
+		"env":          s.Env,
+		"config":       s.Config,
+		"ports":        s.Ports,
+		"volumes":      s.Volumes,
+		"security":     s.Security,
+		"health_check": s.HealthCheck,
+		"install":      s.Install,
+	}
+}
+
+// UpgradePreview shows what upgrading to the latest template version changes.
+func (h *ServerHandler) UpgradePreview(w http.ResponseWriter, r *http.Request) {
+	s, err := h.getServer(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	target, err := h.planUpgrade(s, nil)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, target.plan)
+}
+
+// Upgrade moves a stopped server to the latest version of its template by
+// recreating its container. The body may carry env overrides for config
+// values the new version rejects.
+func (h *ServerHandler) Upgrade(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Env map[string]string `json:"env"`
+	}
+	if r.ContentLength != 0 {
+		if err := decodeJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	s, err := h.getServer(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "server not found")
+		return
+	}
+	target, err := h.planUpgrade(s, req.Env)
+	if err != nil {
+		writeTemplateError(w, err)
+		return
+	}
+	if target.plan.Errors != nil {
+		writeFieldErrors(w, target.plan.Errors)
+		return
+	}
+	if target.plan.FromVersion == target.plan.ToVersion {
+		writeError(w, http.StatusConflict, "server is already on the latest template version")
+		return
+	}
+	if s.ContainerID != "" {
+		if status, err := h.docker.ContainerStatus(r.Context(), s.ContainerID); err == nil && status == "running" {
+			writeError(w, http.StatusConflict, "stop the server before upgrading it")
+			return
+		}
+	}
+
+	if target.next.Image != s.Image {
+		log.Printf("Pulling image %s...", target.next.Image)
+		if err := h.docker.PullImage(r.Context(), target.next.Image); err != nil {
+			log.Printf("Warning: failed to pull image (may already exist locally): %v", err)
+		}
+	}
+	if err := h.recreateContainer(r.Context(), &s, target.next); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to recreate container: %v", err))
+		return
+	}
+
+	portsJSON, _ := json.Marshal(s.Ports)
+	envJSON, _ := json.Marshal(s.Env)
+	configJSON, _ := json.Marshal(s.Config)
+	generatedJSON, _ := json.Marshal(s.Generated)
+	volumesJSON, _ := json.Marshal(s.Volumes)
+	securityJSON, healthJSON, installJSON := "", "", ""
+	if s.Security != nil {
+		b, _ := json.Marshal(s.Security)
+		securityJSON = string(b)
+	}
+	if s.HealthCheck != nil {
+		b, _ := json.Marshal(s.HealthCheck)
+		healthJSON = string(b)
+	}
+	if s.Install != nil {
+		b, _ := json.Marshal(s.Install)
+		installJSON = string(b)
+	}
+	_, err = h.db.Exec(`UPDATE servers SET template_version = ?, container_id = ?, image = ?, ports = ?, env = ?, config = ?, volumes = ?,
+		security = ?, health_check = ?, install = ?, generated = ?, updated_at = ? WHERE id = ?`,
+		s.TemplateVer, s.ContainerID, s.Image, string(portsJSON), string(envJSON), string(configJSON), string(volumesJSON),
+		securityJSON, healthJSON, installJSON, string(generatedJSON), time.Now(), s.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save server")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, target.plan)
+}
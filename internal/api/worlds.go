@@ -0,0 +1,137 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/storage"
+)
+
+// WorldHandler lists, selects and creates worlds for games whose adapter
+// manages them. Selecting or creating a world changes the server's config,
+// so it takes effect on the next start.
+type WorldHandler struct {
+	servers *ServerHandler
+	storage *storage.Service
+}
+
+func NewWorldHandler(servers *ServerHandler, storageSvc *storage.Service) *WorldHandler {
+	return &WorldHandler{servers: servers, storage: storageSvc}
+}
+
+func (h *WorldHandler) List(w http.ResponseWriter, r *http.Request) {
+	s, m, files, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	worlds, err := m.Worlds(r.Context(), files, configValues(s))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list worlds: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, worlds)
+}
+
+// Select makes the server load a world on its next start. Body: {"name"}.
+func (h *WorldHandler) Select(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := decodeJSON(r, &req); err != nil || req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	s, m, files, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	values, err := m.SelectWorld(r.Context(), files, req.Name)
+	if err != nil {
+		writeWorldError(w, err)
+		return
+	}
+	h.apply(w, r, s, m, files, values)
+}
+
+// Create sets up a new world, generated on the server's next start.
+// Body: {"name", "settings"}; settings are game specific.
+func (h *WorldHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name     string          `json:"name"`
+		Settings json.RawMessage `json:"settings"`
+	}
+	if err := decodeJSON(r, &req); err != nil || req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	s, m, files, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+	c, ok := m.(game.WorldCreator)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "this game does not support creating worlds")
+		return
+	}
+	values, err := c.CreateWorld(r.Context(), files, req.Name, req.Settings)
+	if err != nil {
+		writeWorldError(w, err)
+		return
+	}
+	h.apply(w, r, s, m, files, values)
+}
+
+// apply saves the config values that load a world and answers with the
+// updated world list.
+func (h *WorldHandler) apply(w http.ResponseWriter, r *http.Request, s Server, m game.WorldManager, files storage.Volume, values map[string]string) {
+	// Templates without the config fields the adapter uses reject the values
+	s, err := h.servers.changeConfig(r.Context(), s, values)
+	if err != nil {
+		writeConfigError(w, err)
+		return
+	}
+	if err := h.servers.saveConfig(s); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update server")
+		return
+	}
+	worlds, err := m.Worlds(r.Context(), files, configValues(s))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("failed to list worlds: %v", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, worlds)
+}
+
+func (h *WorldHandler) lookup(w http.ResponseWriter, r *http.Request) (Server, game.WorldManager, storage.Volume, bool) {
+	s, err := h.servers.getServer(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "server not found")
+		return s, nil, nil, false
+	}
+	m, ok := game.Get(s.Game).(game.WorldManager)
+	if !ok {
+		writeError(w, http.StatusNotImplemented, "this game does not support managing worlds")
+		return s, nil, nil, false
+	}
+	files, err := h.storage.ForServer(s.ID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to open server data")
+		return s, nil, nil, false
+	}
+	return s, m, files, true
+}
+
+func writeWorldError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, game.ErrUnknownWorld):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, game.ErrWorldExists):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusBadRequest, err.Error())
+	}
+}
@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Entry is a record of a privileged action taken through the panel.
+type Entry struct {
+	ID        int64  `json:"id"`
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	Action    string `json:"action"` // exec, shell
+	ServerID  string `json:"server_id"`
+	Detail    string `json:"detail"`
+	CreatedAt string `json:"created_at"`
+	EndedAt   string `json:"ended_at,omitempty"`
+}
+
+type Service struct {
+	db *sql.DB
+}
+
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// Record adds an entry and returns its ID. Long-running actions such as
+// shell sessions call Finish when they end.
+func (s *Service) Record(userID int64, username, action, serverID, detail string) (int64, error) {
+	result, err := s.db.Exec(
+		`INSERT INTO audit_log (user_id, username, action, server_id, detail) VALUES (?, ?, ?, ?, ?)`,
+		userID, username, action, serverID, detail,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// Finish marks an entry as ended, optionally replacing its detail.
+func (s *Service) Finish(id int64, detail string) error {
+	if detail != "" {
+		_, err := s.db.Exec(`UPDATE audit_log SET ended_at = ?, detail = ? WHERE id = ?`, time.Now().UTC(), detail, id)
+		return err
+	}
+	_, err := s.db.Exec(`UPDATE audit_log SET ended_at = ? WHERE id = ?`, time.Now().UTC(), id)
+	return err
+}
+
+// List returns the most recent entries, optionally for a single server.
+func (s *Service) List(serverID string, limit int) ([]Entry, error) {
+	query := `SELECT id, user_id, username, action, server_id, detail, created_at, COALESCE(ended_at, '') FROM audit_log`
+	args := []any{}
+	if serverID != "" {
+		query += ` WHERE server_id = ?`
+		args = append(args, serverID)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Username, &e.Action, &e.ServerID, &e.Detail, &e.CreatedAt, &e.EndedAt); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
@@ -22,6 +22,7 @@ type Service struct {
 type User struct {
 	ID       int64  `json:"id"`
 	Username string `json:"username"`
+	Admin    bool   `json:"admin"`
 }
 
 func NewService(db *sql.DB) *Service {
@@ -34,13 +35,16 @@ func (s *Service) EnsureDefaultUser(username, password string) error {
 		return err
 	}
 	if count > 0 {
-		return nil
+		// Databases from before admin roles existed: promote the first user
+		_, err := s.db.Exec(`UPDATE users SET is_admin = 1
+			WHERE id = (SELECT MIN(id) FROM users) AND NOT EXISTS (SELECT 1 FROM users WHERE is_admin = 1)`)
+		return err
 	}
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return err
 	}
-	_, err = s.db.Exec("INSERT INTO users (username, password_hash) VALUES (?, ?)", username, string(hash))
+	_, err = s.db.Exec("INSERT INTO users (username, password_hash, is_admin) VALUES (?, ?, 1)", username, string(hash))
 	return err
 }
 
@@ -73,10 +77,10 @@ func (s *Service) ValidateSession(token string) (*User, error) {
 	var user User
 	var expiresAt time.Time
 	err := s.db.QueryRow(`
-		SELECT u.id, u.username, s.expires_at
+		SELECT u.id, u.username, u.is_admin, s.expires_at
 		FROM sessions s JOIN users u ON s.user_id = u.id
 		WHERE s.token = ?
-	`, token).Scan(&user.ID, &user.Username, &expiresAt)
+	`, token).Scan(&user.ID, &user.Username, &user.Admin, &expiresAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, ErrSessionExpired
@@ -1,16 +1,16 @@
 package backup
 
 import (
-	"archive/tar"
 	"compress/gzip"
+	"context"
 	"database/sql"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/reedfamily/reedout/internal/storage"
 )
 
 type Backup struct {
@@ -24,10 +24,11 @@ type Backup struct {
 type Service struct {
 	db      *sql.DB
 	dataDir string
+	storage *storage.Service
 }
 
-func NewService(db *sql.DB, dataDir string) *Service {
-	return &Service{db: db, dataDir: dataDir}
+func NewService(db *sql.DB, dataDir string, storageSvc *storage.Service) *Service {
+	return &Service{db: db, dataDir: dataDir, storage: storageSvc}
 }
 
 // backupsDir returns the path where backups are stored for a server.
@@ -35,16 +36,11 @@ func (s *Service) backupsDir(serverID string) string {
 	return filepath.Join(s.dataDir, "backups", serverID)
 }
 
-// serverDataDir returns the path where server data lives.
-func (s *Service) serverDataDir(serverID string) string {
-	return filepath.Join(s.dataDir, "servers", serverID)
-}
-
-// Create creates a tar.gz backup of a server's data directory.
+// Create creates a tar.gz backup of a server's data.
 func (s *Service) Create(serverID string) (*Backup, error) {
-	srcDir := s.serverDataDir(serverID)
-	if _, err := os.Stat(srcDir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("server data directory not found: %s", srcDir)
+	vol, err := s.storage.ForServer(serverID)
+	if err != nil {
+		return nil, err
 	}
 
 	backupDir := s.backupsDir(serverID)
@@ -57,7 +53,7 @@ func (s *Service) Create(serverID string) (*Backup, error) {
 	filename := fmt.Sprintf("%s-%s.tar.gz", timestamp, id)
 	backupPath := filepath.Join(backupDir, filename)
 
-	if err := createTarGz(backupPath, srcDir); err != nil {
+	if err := createTarGz(backupPath, vol); err != nil {
 		os.Remove(backupPath)
 		return nil, fmt.Errorf("create archive: %w", err)
 	}
@@ -136,7 +132,7 @@ func (s *Service) Delete(serverID, backupID string) error {
 	return err
 }
 
-// Restore extracts a backup archive into the server's data directory.
+// Restore extracts a backup archive into the server's data.
 // The server should be stopped before calling this.
 func (s *Service) Restore(serverID, backupID string) error {
 	path, err := s.FilePath(serverID, backupID)
@@ -144,73 +140,12 @@ func (s *Service) Restore(serverID, backupID string) error {
 		return err
 	}
 
-	destDir := s.serverDataDir(serverID)
-
-	// Clear existing data
-	if err := os.RemoveAll(destDir); err != nil {
-		return fmt.Errorf("clear data directory: %w", err)
-	}
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		return fmt.Errorf("recreate data directory: %w", err)
-	}
-
-	return extractTarGz(path, destDir)
-}
-
-func createTarGz(dest, srcDir string) error {
-	file, err := os.Create(dest)
+	vol, err := s.storage.ForServer(serverID)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	gw := gzip.NewWriter(file)
-	defer gw.Close()
-
-	tw := tar.NewWriter(gw)
-	defer tw.Close()
-
-	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Get path relative to the source directory
-		relPath, err := filepath.Rel(srcDir, path)
-		if err != nil {
-			return err
-		}
-		if relPath == "." {
-			return nil
-		}
-
-		header, err := tar.FileInfoHeader(info, "")
-		if err != nil {
-			return err
-		}
-		header.Name = relPath
 
-		if err := tw.WriteHeader(header); err != nil {
-			return err
-		}
-
-		if info.IsDir() {
-			return nil
-		}
-
-		f, err := os.Open(path)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-
-		_, err = io.Copy(tw, f)
-		return err
-	})
-}
-
-func extractTarGz(src, destDir string) error {
-	file, err := os.Open(src)
+	file, err := os.Open(path)
 	if err != nil {
 		return err
 	}
@@ -222,42 +157,19 @@ func extractTarGz(src, destDir string) error {
 	}
 	defer gr.Close()
 
-	tr := tar.NewReader(gr)
-	for {
-		header, err := tr.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-
-		target := filepath.Join(destDir, header.Name)
+	return vol.Restore(context.Background(), gr)
+}
 
-		// Prevent path traversal
-		if !filepath.HasPrefix(target, destDir) {
-			return fmt.Errorf("invalid path in archive: %s", header.Name)
-		}
+func createTarGz(dest string, vol storage.Volume) error {
+	file, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
 
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
-				return err
-			}
-		case tar.TypeReg:
-			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
-				return err
-			}
-			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
-			if err != nil {
-				return err
-			}
-			if _, err := io.Copy(f, tr); err != nil {
-				f.Close()
-				return err
-			}
-			f.Close()
-		}
+	gw := gzip.NewWriter(file)
+	if err := vol.Archive(context.Background(), gw); err != nil {
+		return err
 	}
-	return nil
+	return gw.Close()
 }
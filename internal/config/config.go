@@ -13,6 +13,8 @@ type Config struct {
 	SecretKey    string
 	DefaultUser  string
 	DefaultPass  string
+	HelperImage  string
+	PublicHost   string // host players connect to; default the host the panel is reached at
 }
 
 func Load() (*Config, error) {
@@ -34,6 +36,8 @@ func Load() (*Config, error) {
 		SecretKey:    envOr("REEDOUT_SECRET", "change-me-in-production"),
 		DefaultUser:  envOr("REEDOUT_DEFAULT_USER", "admin"),
 		DefaultPass:  envOr("REEDOUT_DEFAULT_PASS", "admin"),
+		HelperImage:  envOr("REEDOUT_HELPER_IMAGE", "alpine:3.20"),
+		PublicHost:   os.Getenv("REEDOUT_PUBLIC_HOST"),
 	}, nil
 }
 
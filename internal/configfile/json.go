@@ -0,0 +1,196 @@
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// object is a JSON object that remembers its key order.
+type object struct {
+	keys   []string
+	values map[string]any
+}
+
+func newObject() *object {
+	return &object{values: make(map[string]any)}
+}
+
+func (o *object) set(key string, v any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+// patchJSON sets dotted paths in a JSON document. Key order and unrelated
+// values are preserved; the document is re-indented with two spaces.
+func patchJSON(data []byte, settings []Setting) ([]byte, error) {
+	root := newObject()
+	if len(bytes.TrimSpace(data)) > 0 {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		v, err := decodeValue(dec)
+		if err != nil {
+			return nil, fmt.Errorf("parse json: %w", err)
+		}
+		obj, ok := v.(*object)
+		if !ok {
+			return nil, fmt.Errorf("parse json: top level is not an object")
+		}
+		root = obj
+	}
+
+	for _, s := range settings {
+		parts := strings.Split(s.Key, ".")
+		obj := root
+		for _, p := range parts[:len(parts)-1] {
+			next, ok := obj.values[p].(*object)
+			if !ok {
+				next = newObject()
+				obj.set(p, next)
+			}
+			obj = next
+		}
+		key := parts[len(parts)-1]
+		obj.set(key, jsonValue(s, obj.values[key]))
+	}
+
+	var buf bytes.Buffer
+	if err := encodeValue(&buf, root, ""); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// jsonValue converts a setting to a JSON value, keeping the type of the
+// value it replaces when the new value fits it.
+func jsonValue(s Setting, existing any) any {
+	switch existing.(type) {
+	case json.Number:
+		if _, err := strconv.ParseFloat(s.Value, 64); err == nil {
+			return json.Number(s.Value)
+		}
+	case bool:
+		if b, err := strconv.ParseBool(s.Value); err == nil {
+			return b
+		}
+	case string:
+		return s.Value
+	}
+	v, typed := typedValue(s)
+	if typed && s.Type == "number" {
+		return json.Number(s.Value)
+	}
+	return v
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			obj := newObject()
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := keyTok.(string)
+				if !ok {
+					return nil, fmt.Errorf("unexpected object key %v", keyTok)
+				}
+				v, err := decodeValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				obj.set(key, v)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return obj, nil
+		case '[':
+			list := []any{}
+			for dec.More() {
+				v, err := decodeValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return list, nil
+		}
+		return nil, fmt.Errorf("unexpected delimiter %v", t)
+	}
+	return tok, nil
+}
+
+func encodeValue(w io.Writer, v any, indent string) error {
+	inner := indent + "  "
+	switch t := v.(type) {
+	case *object:
+		if len(t.keys) == 0 {
+			_, err := io.WriteString(w, "{}")
+			return err
+		}
+		io.WriteString(w, "{\n")
+		for i, k := range t.keys {
+			io.WriteString(w, inner)
+			if err := encodeScalar(w, k); err != nil {
+				return err
+			}
+			io.WriteString(w, ": ")
+			if err := encodeValue(w, t.values[k], inner); err != nil {
+				return err
+			}
+			if i < len(t.keys)-1 {
+				io.WriteString(w, ",")
+			}
+			io.WriteString(w, "\n")
+		}
+		_, err := io.WriteString(w, indent+"}")
+		return err
+	case []any:
+		if len(t) == 0 {
+			_, err := io.WriteString(w, "[]")
+			return err
+		}
+		io.WriteString(w, "[\n")
+		for i, item := range t {
+			io.WriteString(w, inner)
+			if err := encodeValue(w, item, inner); err != nil {
+				return err
+			}
+			if i < len(t)-1 {
+				io.WriteString(w, ",")
+			}
+			io.WriteString(w, "\n")
+		}
+		_, err := io.WriteString(w, indent+"]")
+		return err
+	}
+	return encodeScalar(w, v)
+}
+
+func encodeScalar(w io.Writer, v any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return err
+}
@@ -0,0 +1,179 @@
+// Package configfile patches values into game config files in place,
+// keeping unrelated keys, ordering and comments.
+package configfile
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Setting is one value to write into a config file.
+type Setting struct {
+	Key   string // dotted path (JSON, YAML), "section.key" (INI) or plain key
+	Value string
+	Type  string // config field type: text, number, select, toggle
+}
+
+// Patch applies settings to the contents of a config file. data may be empty
+// when the file doesn't exist yet.
+func Patch(format string, data []byte, settings []Setting) ([]byte, error) {
+	switch format {
+	case "properties":
+		return patchProperties(data, settings), nil
+	case "ini":
+		return patchINI(data, settings), nil
+	case "json":
+		return patchJSON(data, settings)
+	case "yaml":
+		return patchYAML(data, settings)
+	}
+	return nil, fmt.Errorf("unsupported config format %q", format)
+}
+
+// typedValue reports how a setting should be written in typed formats.
+func typedValue(s Setting) (any, bool) {
+	switch s.Type {
+	case "number":
+		if _, err := strconv.ParseFloat(s.Value, 64); err == nil {
+			return s.Value, true
+		}
+	case "toggle":
+		if b, err := strconv.ParseBool(s.Value); err == nil {
+			return b, true
+		}
+	}
+	return s.Value, false
+}
+
+// splitLines splits a file into lines, remembering whether it ended in a newline.
+func splitLines(data []byte) ([]string, bool) {
+	text := string(data)
+	if text == "" {
+		return nil, true
+	}
+	trailing := strings.HasSuffix(text, "\n")
+	text = strings.TrimSuffix(text, "\n")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(text, "\n"), trailing
+}
+
+func joinLines(lines []string, trailing bool) []byte {
+	out := strings.Join(lines, "\n")
+	if trailing && len(lines) > 0 {
+		out += "\n"
+	}
+	return []byte(out)
+}
+
+// keyLine matches "key=value", "key: value" and "key = value" lines,
+// capturing everything up to the value.
+var keyLine = regexp.MustCompile(`^(\s*([^=:\s#;!]+)\s*[=:]\s*)(.*)$`)
+
+// patchProperties updates Java .properties files such as server.properties.
+// Keys not already present are appended.
+func patchProperties(data []byte, settings []Setting) []byte {
+	lines, trailing := splitLines(data)
+	pending := make(map[string]string, len(settings))
+	for _, s := range settings {
+		pending[s.Key] = escapeProperty(s.Value)
+	}
+
+	continued := false
+	for i, line := range lines {
+		// Skip continuation lines of multi-line values
+		wasContinued := continued
+		continued = strings.HasSuffix(line, "\\") && !strings.HasSuffix(line, "\\\\")
+		if wasContinued {
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+			continue
+		}
+		m := keyLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if value, ok := pending[m[2]]; ok {
+			lines[i] = m[1] + value
+			delete(pending, m[2])
+		}
+	}
+	for _, s := range settings {
+		if value, ok := pending[s.Key]; ok {
+			lines = append(lines, s.Key+"="+value)
+		}
+	}
+	return joinLines(lines, trailing)
+}
+
+func escapeProperty(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	return v
+}
+
+// patchINI updates INI files. A key without a section refers to the lines
+// before the first section header.
+func patchINI(data []byte, settings []Setting) []byte {
+	lines, trailing := splitLines(data)
+
+	for _, s := range settings {
+		section, key := "", s.Key
+		if i := strings.LastIndex(s.Key, "."); i >= 0 {
+			section, key = s.Key[:i], s.Key[i+1:]
+		}
+
+		// Find the target section's body and the key within it
+		start, end := -1, -1
+		if section == "" {
+			start = 0
+		}
+		found := false
+		for i, line := range lines {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+				if start >= 0 && end < 0 {
+					end = i
+				}
+				if trimmed[1:len(trimmed)-1] == section && start < 0 {
+					start = i + 1
+				}
+				continue
+			}
+			if start < 0 || end >= 0 || trimmed == "" || trimmed[0] == ';' || trimmed[0] == '#' {
+				continue
+			}
+			if m := keyLine.FindStringSubmatch(line); m != nil && m[2] == key {
+				lines[i] = m[1] + s.Value
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+
+		entry := key + "=" + s.Value
+		if start < 0 {
+			// New section at the end of the file
+			if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+				lines = append(lines, "")
+			}
+			lines = append(lines, "["+section+"]", entry)
+			continue
+		}
+		// Insert after the section's last non-blank line
+		if end < 0 {
+			end = len(lines)
+		}
+		at := end
+		for at > start && strings.TrimSpace(lines[at-1]) == "" {
+			at--
+		}
+		lines = append(lines[:at], append([]string{entry}, lines[at:]...)...)
+	}
+	return joinLines(lines, trailing)
+}
@@ -0,0 +1,110 @@
+package configfile
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/storage"
+	"github.com/reedfamily/reedout/internal/templates"
+)
+
+// Service writes file-backed config field values into servers' data.
+type Service struct {
+	db        *sql.DB
+	storage   *storage.Service
+	templates *templates.Store
+}
+
+func NewService(db *sql.DB, storageSvc *storage.Service, templateStore *templates.Store) *Service {
+	return &Service{db: db, storage: storageSvc, templates: templateStore}
+}
+
+// Render patches a server's config files with its current config values.
+// It runs before every start so the game always reads what the panel shows.
+func (s *Service) Render(ctx context.Context, serverID string) error {
+	var templateID, envJSON, configJSON string
+	var version int
+	err := s.db.QueryRow(`SELECT template_id, template_version, env, config FROM servers WHERE id = ?`, serverID).
+		Scan(&templateID, &version, &envJSON, &configJSON)
+	if err != nil {
+		return fmt.Errorf("server not found: %w", err)
+	}
+
+	var tmpl *docker.GameTemplate
+	if version > 0 {
+		tmpl, err = s.templates.GetVersion(templateID, version)
+	} else {
+		tmpl, err = s.templates.Get(templateID)
+	}
+	if errors.Is(err, templates.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var env, config map[string]string
+	json.Unmarshal([]byte(envJSON), &env)
+	json.Unmarshal([]byte(configJSON), &config)
+
+	// Group settings by file, keeping the template's field order
+	type target struct {
+		file, format string
+		settings     []Setting
+	}
+	var targets []*target
+	byFile := map[string]*target{}
+	for _, f := range tmpl.ConfigFields {
+		if f.File == "" {
+			continue
+		}
+		value, ok := config[f.Name()]
+		if !ok && f.EnvVar != "" {
+			value, ok = env[f.EnvVar]
+		}
+		if !ok {
+			value, ok = f.Default, f.Default != ""
+		}
+		if !ok {
+			continue
+		}
+		t := byFile[f.File]
+		if t == nil {
+			t = &target{file: f.File, format: f.Format()}
+			byFile[f.File] = t
+			targets = append(targets, t)
+		}
+		t.settings = append(t.settings, Setting{Key: f.FileKey, Value: value, Type: f.Type})
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	vol, err := s.storage.ForServer(serverID)
+	if err != nil {
+		return err
+	}
+	for _, t := range targets {
+		data, err := vol.ReadFile(ctx, t.file)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("read %s: %w", t.file, err)
+		}
+		patched, err := Patch(t.format, data, t.settings)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.file, err)
+		}
+		if bytes.Equal(patched, data) {
+			continue
+		}
+		if err := vol.WriteFile(ctx, t.file, patched); err != nil {
+			return fmt.Errorf("write %s: %w", t.file, err)
+		}
+	}
+	return nil
+}
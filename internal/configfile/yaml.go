@@ -0,0 +1,104 @@
+package configfile
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// patchYAML sets dotted paths in a YAML document through its node tree, so
+// comments, key order and styles survive.
+func patchYAML(data []byte, settings []Setting) ([]byte, error) {
+	var doc yaml.Node
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("parse yaml: %w", err)
+		}
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("parse yaml: top level is not a mapping")
+	}
+
+	for _, s := range settings {
+		parts := strings.Split(s.Key, ".")
+		node := root
+		for _, p := range parts[:len(parts)-1] {
+			next := mappingValue(node, p)
+			if next == nil || next.Kind != yaml.MappingNode {
+				next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				setMappingValue(node, p, next)
+			}
+			node = next
+		}
+
+		key := parts[len(parts)-1]
+		value := mappingValue(node, key)
+		if value == nil || value.Kind != yaml.ScalarNode {
+			value = &yaml.Node{Kind: yaml.ScalarNode}
+			setMappingValue(node, key, value)
+		}
+		setScalar(value, s)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	enc.Close()
+	return buf.Bytes(), nil
+}
+
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func setMappingValue(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// setScalar writes a setting into a scalar node, keeping its style and,
+// where the value fits, its type.
+func setScalar(n *yaml.Node, s Setting) {
+	n.Value = s.Value
+	switch n.Tag {
+	case "!!int", "!!float", "!!bool":
+		var probe any
+		if yaml.Unmarshal([]byte(s.Value), &probe) == nil && probe != nil {
+			if _, isString := probe.(string); !isString {
+				return
+			}
+		}
+	}
+	if _, typed := typedValue(s); typed {
+		n.Tag = ""
+		n.Style = 0
+		return
+	}
+	// Quote strings that would otherwise read as another type
+	n.Tag = "!!str"
+	var probe any
+	if yaml.Unmarshal([]byte(s.Value), &probe) != nil {
+		n.Style = yaml.DoubleQuotedStyle
+	} else if _, isString := probe.(string); !isString {
+		n.Style = yaml.DoubleQuotedStyle
+	}
+}
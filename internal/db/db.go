@@ -25,9 +25,46 @@ func Migrate(db *sql.DB) error {
 			return fmt.Errorf("migration error: %w\nSQL: %s", err, m)
 		}
 	}
+	for _, c := range columns {
+		if err := addColumn(db, c.table, c.name, c.def); err != nil {
+			return fmt.Errorf("migration error: add %s.%s: %w", c.table, c.name, err)
+		}
+	}
 	return nil
 }
 
+// addColumn adds a column unless it already exists. SQLite has no
+// ALTER TABLE ... ADD COLUMN IF NOT EXISTS.
+func addColumn(db *sql.DB, table, name, def string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			cid        int
+			colName    string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			pk         int
+		)
+		if err := rows.Scan(&cid, &colName, &colType, &notNull, &defaultVal, &pk); err != nil {
+			return err
+		}
+		if colName == name {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, name, def))
+	return err
+}
+
 var migrations = []string{
 	`CREATE TABLE IF NOT EXISTS users (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -85,4 +122,69 @@ var migrations = []string{
 		last_run DATETIME,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	)`,
+	`CREATE TABLE IF NOT EXISTS networks (
+		name TEXT PRIMARY KEY,
+		description TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		username TEXT NOT NULL,
+		action TEXT NOT NULL,
+		server_id TEXT NOT NULL DEFAULT '',
+		detail TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		ended_at DATETIME
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_server ON audit_log(server_id, id)`,
+	`CREATE TABLE IF NOT EXISTS installs (
+		id TEXT PRIMARY KEY,
+		server_id TEXT NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+		status TEXT NOT NULL,
+		exit_code INTEGER NOT NULL DEFAULT -1,
+		error TEXT NOT NULL DEFAULT '',
+		started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		finished_at DATETIME
+	)`,
+	`CREATE TABLE IF NOT EXISTS templates (
+		id TEXT PRIMARY KEY,
+		version INTEGER NOT NULL,
+		source TEXT NOT NULL DEFAULT 'api',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`CREATE TABLE IF NOT EXISTS template_versions (
+		template_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		data TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (template_id, version)
+	)`,
+	`CREATE TABLE IF NOT EXISTS player_sessions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		server_id TEXT NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+		player TEXT NOT NULL,
+		joined_at DATETIME NOT NULL,
+		left_at DATETIME
+	)`,
+	`CREATE INDEX IF NOT EXISTS idx_player_sessions_server ON player_sessions(server_id, joined_at)`,
+}
+
+// columns added to tables after their first release.
+var columns = []struct {
+	table, name, def string
+}{
+	{"users", "is_admin", "INTEGER NOT NULL DEFAULT 0"},
+	{"servers", "template_id", "TEXT NOT NULL DEFAULT ''"},
+	{"servers", "security", "TEXT NOT NULL DEFAULT ''"},
+	{"servers", "networks", "TEXT NOT NULL DEFAULT '[]'"},
+	{"servers", "storage", "TEXT NOT NULL DEFAULT '{}'"},
+	{"servers", "health_check", "TEXT NOT NULL DEFAULT ''"},
+	{"servers", "install", "TEXT NOT NULL DEFAULT ''"},
+	{"servers", "template_version", "INTEGER NOT NULL DEFAULT 0"},
+	{"servers", "generated", "TEXT NOT NULL DEFAULT '{}'"},
+	{"servers", "config", "TEXT NOT NULL DEFAULT '{}'"},
+	{"templates", "extends", "TEXT NOT NULL DEFAULT ''"},
+	{"template_versions", "definition", "TEXT NOT NULL DEFAULT ''"},
 }
@@ -0,0 +1,58 @@
+package docker
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// AdapterSpec declares a game adapter in a template, for games without one
+// in code. Patterns are regular expressions matched against each log line:
+// join and leave capture the player in a "player" group, chat captures
+// "player" and "message", and error may capture "message" (the whole line
+// otherwise).
+type AdapterSpec struct {
+	Patterns LogPatterns     `json:"patterns"`
+	Commands AdapterCommands `json:"commands"`
+}
+
+type LogPatterns struct {
+	Join  string `json:"join,omitempty"`
+	Leave string `json:"leave,omitempty"`
+	Chat  string `json:"chat,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// AdapterCommands are console commands written to the server's stdin.
+type AdapterCommands struct {
+	Stop string `json:"stop,omitempty"`
+	List string `json:"list,omitempty"` // lists online players
+	Save string `json:"save,omitempty"` // writes the world to disk
+}
+
+// Validate checks that the patterns compile and capture the groups they need.
+func (a *AdapterSpec) Validate() error {
+	checks := []struct {
+		name, pattern string
+		groups        []string
+	}{
+		{"join", a.Patterns.Join, []string{"player"}},
+		{"leave", a.Patterns.Leave, []string{"player"}},
+		{"chat", a.Patterns.Chat, []string{"player", "message"}},
+		{"error", a.Patterns.Error, nil},
+	}
+	for _, c := range checks {
+		if c.pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(c.pattern)
+		if err != nil {
+			return fmt.Errorf("%s pattern: %w", c.name, err)
+		}
+		for _, g := range c.groups {
+			if re.SubexpIndex(g) < 0 {
+				return fmt.Errorf("%s pattern: missing (?P<%s>...) group", c.name, g)
+			}
+		}
+	}
+	return nil
+}
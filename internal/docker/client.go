@@ -4,19 +4,27 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/image"
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 )
 
 type Client struct {
 	cli *client.Client
+
+	// HelperImage is the image used for short-lived helper containers
+	// (REEDOUT_HELPER_IMAGE).
+	HelperImage string
 }
 
 type ContainerConfig struct {
@@ -27,12 +35,22 @@ type ContainerConfig struct {
 	Volumes     map[string]string
 	MemoryLimit int64
 	CPULimit    float64
+	Security    *SecurityProfile
+	HealthCheck *HealthCheck
+	// Network is the primary network the container is created on; Networks
+	// are joined right after creation. Aliases apply to all of them.
+	Network  string
+	Networks []string
+	Aliases  []string
 }
 
 type PortMapping struct {
+	Name      string `json:"name,omitempty"`
+	Role      string `json:"role,omitempty"` // game, query, rcon, web
 	Host      string `json:"host"`
 	Container string `json:"container"`
 	Protocol  string `json:"protocol"`
+	Internal  bool   `json:"internal,omitempty"` // only reachable on the server's networks, not published
 }
 
 func NewClient() (*Client, error) {
@@ -65,23 +83,30 @@ func (c *Client) CreateContainer(ctx context.Context, cfg ContainerConfig) (stri
 
 	exposedPorts := nat.PortSet{}
 	portBindings := nat.PortMap{}
-	for _, p := range cfg.Ports {
-		proto := p.Protocol
-		if proto == "" {
-			proto = "tcp"
+	for _, mapping := range cfg.Ports {
+		for _, p := range mapping.Expand() {
+			containerPort := nat.Port(p.Container + "/" + p.Proto())
+			exposedPorts[containerPort] = struct{}{}
+			if !p.Internal {
+				// A container port may be published on several host ports
+				portBindings[containerPort] = append(portBindings[containerPort], nat.PortBinding{HostPort: p.Host})
+			}
 		}
-		containerPort := nat.Port(p.Container + "/" + proto)
-		exposedPorts[containerPort] = struct{}{}
-		portBindings[containerPort] = []nat.PortBinding{{HostPort: p.Host}}
 	}
 
+	// Absolute sources are host bind mounts; anything else names a volume,
+	// optionally followed by a subdirectory of it ("volume/sub/dir")
 	mounts := make([]mount.Mount, 0, len(cfg.Volumes))
-	for hostPath, containerPath := range cfg.Volumes {
-		mounts = append(mounts, mount.Mount{
-			Type:   mount.TypeBind,
-			Source: hostPath,
-			Target: containerPath,
-		})
+	for source, containerPath := range cfg.Volumes {
+		m := mount.Mount{Type: mount.TypeBind, Source: source, Target: containerPath}
+		if !filepath.IsAbs(source) {
+			m.Type = mount.TypeVolume
+			if name, sub, ok := strings.Cut(source, "/"); ok {
+				m.Source = name
+				m.VolumeOptions = &mount.VolumeOptions{Subpath: sub}
+			}
+		}
+		mounts = append(mounts, m)
 	}
 
 	hostCfg := &container.HostConfig{
@@ -96,17 +121,43 @@ func (c *Client) CreateContainer(ctx context.Context, cfg ContainerConfig) (stri
 		hostCfg.NanoCPUs = int64(cfg.CPULimit * 1e9)
 	}
 
-	resp, err := c.cli.ContainerCreate(ctx, &container.Config{
+	containerCfg := &container.Config{
 		Image:        cfg.Image,
 		Env:          env,
 		ExposedPorts: exposedPorts,
 		Tty:          true,
 		OpenStdin:    true,
 		AttachStdin:  true,
-	}, hostCfg, nil, nil, cfg.Name)
+	}
+	if cfg.HealthCheck != nil && cfg.HealthCheck.Type == HealthCommand {
+		containerCfg.Healthcheck = cfg.HealthCheck.dockerConfig()
+	}
+	if cfg.Security != nil {
+		if err := applySecurity(containerCfg, hostCfg, cfg.Security); err != nil {
+			return "", err
+		}
+	}
+
+	var netCfg *network.NetworkingConfig
+	if cfg.Network != "" {
+		hostCfg.NetworkMode = container.NetworkMode(cfg.Network)
+		netCfg = &network.NetworkingConfig{
+			EndpointsConfig: map[string]*network.EndpointSettings{
+				cfg.Network: {Aliases: cfg.Aliases},
+			},
+		}
+	}
+
+	resp, err := c.cli.ContainerCreate(ctx, containerCfg, hostCfg, netCfg, nil, cfg.Name)
 	if err != nil {
 		return "", fmt.Errorf("create container: %w", err)
 	}
+	for _, n := range cfg.Networks {
+		if err := c.ConnectNetwork(ctx, n, resp.ID, cfg.Aliases); err != nil {
+			c.RemoveContainer(context.Background(), resp.ID)
+			return "", err
+		}
+	}
 	return resp.ID, nil
 }
 
@@ -153,6 +204,45 @@ func (c *Client) ContainerLogs(ctx context.Context, id string, tail string) (io.
 	})
 }
 
+// FollowLogs streams a container's output since the given time as plain
+// text, each line prefixed with its RFC 3339 timestamp and a space.
+func (c *Client) FollowLogs(ctx context.Context, id string, since time.Time) (io.ReadCloser, error) {
+	inspect, err := c.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := c.cli.ContainerLogs(ctx, id, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     true,
+		Timestamps: true,
+		Since:      since.Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if inspect.Config.Tty {
+		return logs, nil
+	}
+	// Without a TTY stdout and stderr are multiplexed
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(pw, pw, logs)
+		pw.CloseWithError(err)
+	}()
+	return &demuxedLogs{PipeReader: pr, logs: logs}, nil
+}
+
+type demuxedLogs struct {
+	*io.PipeReader
+	logs io.Closer
+}
+
+func (d *demuxedLogs) Close() error {
+	d.logs.Close()
+	return d.PipeReader.Close()
+}
+
 func (c *Client) ContainerStats(ctx context.Context, id string) (container.StatsResponseReader, error) {
 	return c.cli.ContainerStats(ctx, id, true)
 }
@@ -162,9 +252,12 @@ func (c *Client) ContainerStatsOnce(ctx context.Context, id string) (container.S
 	return c.cli.ContainerStats(ctx, id, false)
 }
 
-func (c *Client) ContainerExecAttach(ctx context.Context, containerID string, cmd []string) (types_HijackedResponse, error) {
+// ContainerExecAttach starts cmd in a TTY exec session and returns the exec
+// ID along with the hijacked connection carrying its input and output.
+func (c *Client) ContainerExecAttach(ctx context.Context, containerID string, cmd []string) (string, types_HijackedResponse, error) {
 	execCfg := container.ExecOptions{
 		Cmd:          cmd,
+		Env:          []string{"TERM=xterm-256color"},
 		AttachStdin:  true,
 		AttachStdout: true,
 		AttachStderr: true,
@@ -172,9 +265,24 @@ func (c *Client) ContainerExecAttach(ctx context.Context, containerID string, cm
 	}
 	exec, err := c.cli.ContainerExecCreate(ctx, containerID, execCfg)
 	if err != nil {
-		return types_HijackedResponse{}, err
+		return "", types_HijackedResponse{}, err
 	}
-	return c.cli.ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{Tty: true})
+	resp, err := c.cli.ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{Tty: true})
+	return exec.ID, resp, err
+}
+
+// ExecResize changes the TTY size of an exec session.
+func (c *Client) ExecResize(ctx context.Context, execID string, cols, rows uint) error {
+	return c.cli.ContainerExecResize(ctx, execID, container.ResizeOptions{Width: cols, Height: rows})
+}
+
+// ExecRunning reports whether an exec session's process is still alive.
+func (c *Client) ExecRunning(ctx context.Context, execID string) (bool, error) {
+	resp, err := c.cli.ContainerExecInspect(ctx, execID)
+	if err != nil {
+		return false, err
+	}
+	return resp.Running, nil
 }
 
 // Attach to the container's main process stdin/stdout
@@ -209,11 +317,7 @@ func ParsePortMappings(ports []string) []PortMapping {
 func FormatPortMappings(ports []PortMapping) []string {
 	var result []string
 	for _, p := range ports {
-		proto := p.Protocol
-		if proto == "" {
-			proto = "tcp"
-		}
-		result = append(result, p.Host+":"+p.Container+"/"+proto)
+		result = append(result, p.Host+":"+p.Container+"/"+p.Proto())
 	}
 	return result
 }
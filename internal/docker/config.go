@@ -0,0 +1,162 @@
+package docker
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Validate checks that a config field is well formed and its default is a
+// valid value for it.
+func (f ConfigField) Validate() error {
+	if f.Key == "" {
+		return fmt.Errorf("config field missing key")
+	}
+	if f.Remove {
+		return fmt.Errorf("config field %s: remove only applies to templates that extend another", f.Key)
+	}
+	switch f.Type {
+	case "text", "number", "select", "toggle":
+	default:
+		return fmt.Errorf("config field %s: unknown type %q", f.Key, f.Type)
+	}
+	if f.Type == "select" && len(f.Options) == 0 {
+		return fmt.Errorf("config field %s: select requires options", f.Key)
+	}
+	if f.Min != nil && f.Max != nil && *f.Min > *f.Max {
+		return fmt.Errorf("config field %s: min is greater than max", f.Key)
+	}
+	if f.Pattern != "" {
+		if _, err := regexp.Compile(f.Pattern); err != nil {
+			return fmt.Errorf("config field %s: invalid pattern: %w", f.Key, err)
+		}
+	}
+	if f.File != "" {
+		if filepath.IsAbs(f.File) || !filepath.IsLocal(f.File) {
+			return fmt.Errorf("config field %s: file must be a relative path inside the data directory", f.Key)
+		}
+		if f.FileKey == "" {
+			return fmt.Errorf("config field %s: file needs a file_key", f.Key)
+		}
+		if f.Format() == "" {
+			return fmt.Errorf("config field %s: unknown file format for %s", f.Key, f.File)
+		}
+	}
+	if f.Default != "" {
+		if err := f.Check(f.Default); err != nil {
+			return fmt.Errorf("config field %s: default %w", f.Key, err)
+		}
+	}
+	return nil
+}
+
+// Check validates a single value against the field's type and constraints.
+func (f ConfigField) Check(value string) error {
+	if value == "" {
+		if f.Required {
+			return fmt.Errorf("is required")
+		}
+		return nil
+	}
+	switch f.Type {
+	case "number":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("must be a number")
+		}
+		if f.Min != nil && n < *f.Min {
+			return fmt.Errorf("must be at least %s", strconv.FormatFloat(*f.Min, 'f', -1, 64))
+		}
+		if f.Max != nil && n > *f.Max {
+			return fmt.Errorf("must be at most %s", strconv.FormatFloat(*f.Max, 'f', -1, 64))
+		}
+	case "select":
+		if !slices.Contains(f.Options, value) {
+			return fmt.Errorf("must be one of %v", f.Options)
+		}
+	case "toggle":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("must be true or false")
+		}
+	}
+	if f.Pattern != "" {
+		if ok, _ := regexp.MatchString(f.Pattern, value); !ok {
+			return fmt.Errorf("must match %s", f.Pattern)
+		}
+	}
+	return nil
+}
+
+// Name returns the key a field's value is submitted and stored under: its
+// env var when it has one, otherwise its key.
+func (f ConfigField) Name() string {
+	if f.EnvVar != "" {
+		return f.EnvVar
+	}
+	return f.Key
+}
+
+// Format returns the format of the field's target file, from FileFormat or
+// the file extension. It is empty if the format is not supported.
+func (f ConfigField) Format() string {
+	format := f.FileFormat
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(f.File)) {
+		case ".properties":
+			format = "properties"
+		case ".json":
+			format = "json"
+		case ".yml", ".yaml":
+			format = "yaml"
+		case ".ini", ".cfg", ".conf":
+			format = "ini"
+		}
+	}
+	switch format {
+	case "properties", "json", "yaml", "ini":
+		return format
+	}
+	return ""
+}
+
+// ResolveConfig validates values against a template's config fields and fills
+// in defaults for fields that were omitted. current holds the server's existing
+// values, if any, and is used in place of defaults. Values for unknown fields
+// are rejected. On failure the returned map holds one message per bad field.
+func ResolveConfig(fields []ConfigField, values, current map[string]string) (map[string]string, map[string]string) {
+	resolved := make(map[string]string)
+	errs := make(map[string]string)
+
+	known := make(map[string]bool)
+	for _, f := range fields {
+		name := f.Name()
+		known[name] = true
+
+		value, ok := values[name]
+		if !ok {
+			if v, found := current[name]; found {
+				value = v
+			} else {
+				value = f.Default
+			}
+		}
+		if err := f.Check(value); err != nil {
+			errs[name] = err.Error()
+			continue
+		}
+		resolved[name] = value
+	}
+	for name := range values {
+		if !known[name] {
+			errs[name] = "is not a config field of this template"
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return resolved, nil
+}
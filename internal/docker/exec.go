@@ -0,0 +1,95 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// ExecResult is the outcome of a one-off command run inside a container.
+type ExecResult struct {
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+	ExitCode   int    `json:"exit_code"`
+	DurationMS int64  `json:"duration_ms"`
+	Truncated  bool   `json:"truncated"`
+	TimedOut   bool   `json:"timed_out"`
+}
+
+// Exec runs cmd inside a running container and collects its output. Each of
+// stdout and stderr keeps at most maxOutput bytes; the rest is discarded.
+// When ctx expires the output so far is returned with TimedOut set and an
+// exit code of -1. Docker cannot kill an exec, so the process may keep running.
+func (c *Client) Exec(ctx context.Context, containerID string, cmd []string, maxOutput int) (*ExecResult, error) {
+	exec, err := c.cli.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create exec: %w", err)
+	}
+
+	start := time.Now()
+	attach, err := c.cli.ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("attach exec: %w", err)
+	}
+	defer attach.Close()
+
+	stdout := &cappedBuffer{max: maxOutput}
+	stderr := &cappedBuffer{max: maxOutput}
+	done := make(chan error, 1)
+	go func() {
+		_, err := stdcopy.StdCopy(stdout, stderr, attach.Reader)
+		done <- err
+	}()
+
+	result := &ExecResult{ExitCode: -1}
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, fmt.Errorf("read exec output: %w", err)
+		}
+	case <-ctx.Done():
+		attach.Close()
+		<-done
+		result.TimedOut = true
+	}
+	result.DurationMS = time.Since(start).Milliseconds()
+	result.Stdout = stdout.buf.String()
+	result.Stderr = stderr.buf.String()
+	result.Truncated = stdout.truncated || stderr.truncated
+
+	if !result.TimedOut {
+		inspect, err := c.cli.ContainerExecInspect(context.Background(), exec.ID)
+		if err != nil {
+			return nil, fmt.Errorf("inspect exec: %w", err)
+		}
+		result.ExitCode = inspect.ExitCode
+	}
+	return result, nil
+}
+
+// cappedBuffer keeps the first max bytes written to it and drops the rest
+// while still reporting success, so the stream keeps draining.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); room < len(p) {
+		b.truncated = true
+		if room > 0 {
+			b.buf.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
@@ -0,0 +1,118 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// Health check types. Command checks run inside the container as a Docker
+// HEALTHCHECK; tcp and query checks are probed from the panel.
+const (
+	HealthCommand = "command"
+	HealthTCP     = "tcp"
+	HealthQuery   = "query"
+)
+
+// HealthCheck describes how to tell that a server is up and accepting players.
+type HealthCheck struct {
+	Type        string   `json:"type"`
+	Command     []string `json:"command,omitempty"`
+	Port        string   `json:"port,omitempty"` // container port probed by tcp and query checks; default the query port
+	Interval    string   `json:"interval,omitempty"`
+	Timeout     string   `json:"timeout,omitempty"`
+	Retries     int      `json:"retries,omitempty"`
+	StartPeriod string   `json:"start_period,omitempty"`
+}
+
+// Validate checks the health check for missing or malformed settings.
+func (h *HealthCheck) Validate() error {
+	switch h.Type {
+	case HealthCommand:
+		if len(h.Command) == 0 {
+			return fmt.Errorf("command health check needs a command")
+		}
+	case HealthTCP, HealthQuery:
+	default:
+		return fmt.Errorf("unknown health check type %q", h.Type)
+	}
+	for _, d := range []string{h.Interval, h.Timeout, h.StartPeriod} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(d); err != nil {
+			return fmt.Errorf("invalid duration %q", d)
+		}
+	}
+	return nil
+}
+
+// IntervalDuration returns the time between probes (default 10s).
+func (h *HealthCheck) IntervalDuration() time.Duration {
+	return parseDurationOr(h.Interval, 10*time.Second)
+}
+
+// TimeoutDuration returns how long a single probe may take (default 5s).
+func (h *HealthCheck) TimeoutDuration() time.Duration {
+	return parseDurationOr(h.Timeout, 5*time.Second)
+}
+
+// StartPeriodDuration returns the grace period after start during which
+// failures are not counted (default 60s).
+func (h *HealthCheck) StartPeriodDuration() time.Duration {
+	return parseDurationOr(h.StartPeriod, 60*time.Second)
+}
+
+// RetryCount returns the consecutive failures needed to become unhealthy (default 3).
+func (h *HealthCheck) RetryCount() int {
+	if h.Retries > 0 {
+		return h.Retries
+	}
+	return 3
+}
+
+func parseDurationOr(s string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+func (h *HealthCheck) dockerConfig() *container.HealthConfig {
+	return &container.HealthConfig{
+		Test:        append([]string{"CMD"}, h.Command...),
+		Interval:    h.IntervalDuration(),
+		Timeout:     h.TimeoutDuration(),
+		StartPeriod: h.StartPeriodDuration(),
+		Retries:     h.RetryCount(),
+	}
+}
+
+// ContainerIP returns an address the panel can reach the container on.
+// Shared networks are internal, so the server's own network is preferred.
+func (c *Client) ContainerIP(ctx context.Context, id string) (string, error) {
+	resp, err := c.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if resp.NetworkSettings == nil {
+		return "", fmt.Errorf("container has no network settings")
+	}
+	var fallback string
+	for name, ep := range resp.NetworkSettings.Networks {
+		if ep == nil || ep.IPAddress == "" {
+			continue
+		}
+		if !strings.HasPrefix(name, SharedNetwork("")) {
+			return ep.IPAddress, nil
+		}
+		fallback = ep.IPAddress
+	}
+	if fallback == "" {
+		return "", fmt.Errorf("container has no IP address")
+	}
+	return fallback, nil
+}
@@ -0,0 +1,106 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// InstallConfig is a template's install step: a script run once in a
+// throwaway container with the server's data mounted, before the first start.
+type InstallConfig struct {
+	Image      string            `json:"image"`
+	Script     string            `json:"script"`
+	Entrypoint string            `json:"entrypoint,omitempty"` // default /bin/sh
+	Env        map[string]string `json:"env,omitempty"`
+	MountPath  string            `json:"mount_path,omitempty"` // default /mnt/server
+}
+
+// Validate checks the install step for missing settings.
+func (i *InstallConfig) Validate() error {
+	if i.Image == "" || i.Script == "" {
+		return fmt.Errorf("install needs an image and a script")
+	}
+	return nil
+}
+
+// InstallerSpec describes one run of an installer container.
+type InstallerSpec struct {
+	Name   string
+	Config InstallConfig
+	Env    map[string]string // server env; Config.Env is applied on top
+	Source string            // host path or volume name holding the server's data
+}
+
+// RunInstaller runs an install script to completion, streaming its combined
+// output to out, and returns the script's exit code. The container is
+// removed afterwards.
+func (c *Client) RunInstaller(ctx context.Context, spec InstallerSpec, out io.Writer) (int64, error) {
+	cfg := spec.Config
+	entrypoint := cfg.Entrypoint
+	if entrypoint == "" {
+		entrypoint = "/bin/sh"
+	}
+	mountPath := cfg.MountPath
+	if mountPath == "" {
+		mountPath = "/mnt/server"
+	}
+
+	env := make([]string, 0, len(spec.Env)+len(cfg.Env))
+	for k, v := range spec.Env {
+		if _, ok := cfg.Env[k]; !ok {
+			env = append(env, k+"="+v)
+		}
+	}
+	env = append(env, FormatEnv(cfg.Env)...)
+
+	if err := c.PullImage(ctx, cfg.Image); err != nil {
+		fmt.Fprintf(out, "Warning: failed to pull %s (may already exist locally): %v\n", cfg.Image, err)
+	}
+
+	mountType := mount.TypeBind
+	if !filepath.IsAbs(spec.Source) {
+		mountType = mount.TypeVolume
+	}
+	resp, err := c.cli.ContainerCreate(ctx, &container.Config{
+		Image:      cfg.Image,
+		Entrypoint: []string{entrypoint},
+		Cmd:        []string{"-c", cfg.Script},
+		Env:        env,
+		WorkingDir: mountPath,
+		Labels:     map[string]string{"reedout.installer": "true"},
+	}, &container.HostConfig{
+		Mounts: []mount.Mount{{Type: mountType, Source: spec.Source, Target: mountPath}},
+	}, nil, nil, spec.Name)
+	if err != nil {
+		return -1, fmt.Errorf("create installer container: %w", err)
+	}
+	defer c.RemoveContainer(context.Background(), resp.ID)
+
+	waitCh, errCh := c.cli.ContainerWait(ctx, resp.ID, container.WaitConditionNextExit)
+	if err := c.cli.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return -1, fmt.Errorf("start installer container: %w", err)
+	}
+
+	logs, err := c.cli.ContainerLogs(ctx, resp.ID, container.LogsOptions{ShowStdout: true, ShowStderr: true, Follow: true})
+	if err != nil {
+		return -1, fmt.Errorf("installer logs: %w", err)
+	}
+	defer logs.Close()
+	stdcopy.StdCopy(out, out, logs)
+
+	select {
+	case res := <-waitCh:
+		if res.Error != nil {
+			return -1, fmt.Errorf("installer: %s", res.Error.Message)
+		}
+		return res.StatusCode, nil
+	case err := <-errCh:
+		return -1, fmt.Errorf("wait for installer: %w", err)
+	}
+}
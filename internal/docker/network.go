@@ -0,0 +1,90 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+)
+
+var networkNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,62}$`)
+
+// ServerNetwork returns the name of a server's private network.
+func ServerNetwork(serverID string) string {
+	return "reedout-" + serverID
+}
+
+// SharedNetwork returns the Docker network name for a named shared network.
+func SharedNetwork(name string) string {
+	return "reedout-net-" + name
+}
+
+// ValidNetworkName reports whether name can be used for a shared network.
+func ValidNetworkName(name string) bool {
+	return networkNameRe.MatchString(name)
+}
+
+// NetworkAlias converts a server name into a DNS-friendly alias.
+func NetworkAlias(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// EnsureNetwork creates a bridge network unless it already exists.
+// Internal networks have no route outside the host, so containers on them
+// can only reach each other.
+func (c *Client) EnsureNetwork(ctx context.Context, name string, internal bool) error {
+	if _, err := c.cli.NetworkInspect(ctx, name, network.InspectOptions{}); err == nil {
+		return nil
+	} else if !client.IsErrNotFound(err) {
+		return fmt.Errorf("inspect network: %w", err)
+	}
+	_, err := c.cli.NetworkCreate(ctx, name, network.CreateOptions{
+		Driver:   "bridge",
+		Internal: internal,
+		Labels:   map[string]string{"reedout.managed": "true"},
+	})
+	if err != nil {
+		return fmt.Errorf("create network: %w", err)
+	}
+	return nil
+}
+
+// RemoveNetwork deletes a network, ignoring networks that are already gone.
+func (c *Client) RemoveNetwork(ctx context.Context, name string) error {
+	if err := c.cli.NetworkRemove(ctx, name); err != nil && !client.IsErrNotFound(err) {
+		return fmt.Errorf("remove network: %w", err)
+	}
+	return nil
+}
+
+// ConnectNetwork attaches a container to a network under the given aliases.
+func (c *Client) ConnectNetwork(ctx context.Context, name, containerID string, aliases []string) error {
+	err := c.cli.NetworkConnect(ctx, name, containerID, &network.EndpointSettings{Aliases: aliases})
+	if err != nil && !strings.Contains(err.Error(), "already exists") {
+		return fmt.Errorf("connect network %s: %w", name, err)
+	}
+	return nil
+}
+
+// DisconnectNetwork detaches a container from a network.
+func (c *Client) DisconnectNetwork(ctx context.Context, name, containerID string) error {
+	err := c.cli.NetworkDisconnect(ctx, name, containerID, true)
+	if err != nil && !client.IsErrNotFound(err) && !strings.Contains(err.Error(), "is not connected") {
+		return fmt.Errorf("disconnect network %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Port roles tell the panel and game adapters what a port is for.
+const (
+	PortGame  = "game"
+	PortQuery = "query"
+	PortRCON  = "rcon"
+	PortWeb   = "web"
+)
+
+// UnmarshalJSON accepts a port object or the older "host:container/proto"
+// string form.
+func (p *PortMapping) UnmarshalJSON(data []byte) error {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		parsed := ParsePortMappings([]string{s})
+		if len(parsed) == 0 {
+			return fmt.Errorf("invalid port %q", s)
+		}
+		*p = parsed[0]
+		return nil
+	}
+	type plain PortMapping
+	return json.Unmarshal(data, (*plain)(p))
+}
+
+// maxRange bounds the number of ports one mapping can publish.
+const maxRange = 100
+
+// Validate checks a port's numbers, protocol and role. Internal ports don't
+// need a host port. A mapping can cover a range of ports, written
+// "start-end", when the host and container ranges have the same length.
+func (p PortMapping) Validate() error {
+	cStart, cEnd, ok := portRange(p.Container)
+	if !ok {
+		return fmt.Errorf("port %s: invalid container port %q", p.Name, p.Container)
+	}
+	if !p.Internal && !isPlaceholder(p.Host) {
+		hStart, hEnd, ok := portRange(p.Host)
+		if !ok {
+			return fmt.Errorf("port %s: invalid host port %q", p.Name, p.Host)
+		}
+		if hEnd-hStart != cEnd-cStart {
+			return fmt.Errorf("port %s: host and container ranges differ in length", p.Name)
+		}
+	}
+	switch p.Proto() {
+	case "tcp", "udp":
+	default:
+		return fmt.Errorf("port %s: unknown protocol %q", p.Name, p.Protocol)
+	}
+	switch p.Role {
+	case "", PortGame, PortQuery, PortRCON, PortWeb:
+	default:
+		return fmt.Errorf("port %s: unknown role %q", p.Name, p.Role)
+	}
+	return nil
+}
+
+// Proto returns the port's protocol in lower case, tcp if none is set.
+func (p PortMapping) Proto() string {
+	if p.Protocol == "" {
+		return "tcp"
+	}
+	return strings.ToLower(p.Protocol)
+}
+
+func validPort(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n > 0 && n <= 65535
+}
+
+// portRange parses a port or a "start-end" range.
+func portRange(s string) (start, end int, ok bool) {
+	first, last, isRange := strings.Cut(s, "-")
+	if !isRange {
+		last = first
+	}
+	if !validPort(first) || !validPort(last) {
+		return 0, 0, false
+	}
+	start, _ = strconv.Atoi(first)
+	end, _ = strconv.Atoi(last)
+	return start, end, start <= end && end-start < maxRange
+}
+
+// FirstPort returns the first port of a port or range, e.g. "2456" for
+// "2456-2458".
+func FirstPort(s string) string {
+	first, _, _ := strings.Cut(s, "-")
+	return first
+}
+
+// Expand returns one mapping per port of a range. Mappings of a single port,
+// or with ports that don't parse, are returned as they are.
+func (p PortMapping) Expand() []PortMapping {
+	cStart, cEnd, ok := portRange(p.Container)
+	if !ok || cStart == cEnd {
+		return []PortMapping{p}
+	}
+	hStart, _, hostOK := portRange(p.Host)
+	ports := make([]PortMapping, 0, cEnd-cStart+1)
+	for i := 0; i <= cEnd-cStart; i++ {
+		q := p
+		q.Container = strconv.Itoa(cStart + i)
+		if hostOK {
+			q.Host = strconv.Itoa(hStart + i)
+		}
+		ports = append(ports, q)
+	}
+	return ports
+}
+
+func isPlaceholder(s string) bool {
+	return len(s) > 2 && s[0] == '{' && s[len(s)-1] == '}'
+}
+
+// NamePorts fills in missing port names and roles. An unnamed first port is
+// the game port; other unnamed ports are named after their role, or their
+// index when that name is taken.
+func NamePorts(ports []PortMapping) {
+	taken := make(map[string]bool, len(ports))
+	for _, p := range ports {
+		if p.Name != "" {
+			taken[p.Name] = true
+		}
+	}
+	for i := range ports {
+		p := &ports[i]
+		if p.Role == "" && i == 0 {
+			p.Role = PortGame
+		}
+		if p.Name != "" {
+			continue
+		}
+		p.Name = p.Role
+		if p.Name == "" || taken[p.Name] {
+			p.Name = strconv.Itoa(i)
+		}
+		taken[p.Name] = true
+	}
+}
+
+// FindPort returns the first port with the given role.
+func FindPort(ports []PortMapping, role string) *PortMapping {
+	for i := range ports {
+		if ports[i].Role == role {
+			return &ports[i]
+		}
+	}
+	return nil
+}
+
+// QueryPort returns the port status queries should use: the query port if
+// there is one, otherwise the game port.
+func QueryPort(ports []PortMapping) *PortMapping {
+	if p := FindPort(ports, PortQuery); p != nil {
+		return p
+	}
+	return FindPort(ports, PortGame)
+}
+
+// udpOnly reports whether a container port is mapped over UDP only. An
+// empty port means the query port.
+func udpOnly(ports []PortMapping, port string) bool {
+	if port == "" {
+		p := QueryPort(ports)
+		return p != nil && p.Proto() == "udp"
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return false
+	}
+	udp := false
+	for _, p := range ports {
+		start, end, ok := portRange(p.Container)
+		if !ok || n < start || n > end {
+			continue
+		}
+		if p.Proto() != "udp" {
+			return false
+		}
+		udp = true
+	}
+	return udp
+}
@@ -0,0 +1,142 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+// SecurityProfile restricts what a game container may do. Templates opt in
+// by setting "security"; without one the container runs with Docker's defaults.
+type SecurityProfile struct {
+	User            string            `json:"user,omitempty"` // "uid:gid"
+	CapDropAll      bool              `json:"cap_drop_all"`
+	CapAdd          []string          `json:"cap_add,omitempty"`
+	NoNewPrivileges bool              `json:"no_new_privileges"`
+	ReadOnlyRootfs  bool              `json:"read_only_rootfs"`
+	Tmpfs           map[string]string `json:"tmpfs,omitempty"`   // mount point -> mount options
+	Seccomp         string            `json:"seccomp,omitempty"` // path to a seccomp profile JSON file
+}
+
+// EffectiveSecurity is the security configuration Docker reports for a container.
+type EffectiveSecurity struct {
+	User            string            `json:"user"`
+	Privileged      bool              `json:"privileged"`
+	CapAdd          []string          `json:"cap_add"`
+	CapDrop         []string          `json:"cap_drop"`
+	NoNewPrivileges bool              `json:"no_new_privileges"`
+	ReadOnlyRootfs  bool              `json:"read_only_rootfs"`
+	Tmpfs           map[string]string `json:"tmpfs"`
+	Seccomp         string            `json:"seccomp"` // "default", "custom" or "unconfined"
+}
+
+// ParseUser splits a "uid:gid" string. A missing gid defaults to the uid.
+func ParseUser(user string) (uid, gid int, err error) {
+	uidStr, gidStr, found := strings.Cut(user, ":")
+	uid, err = strconv.Atoi(uidStr)
+	if err != nil || uid < 0 {
+		return 0, 0, fmt.Errorf("invalid uid in %q: user must be numeric uid:gid", user)
+	}
+	if !found {
+		return uid, uid, nil
+	}
+	gid, err = strconv.Atoi(gidStr)
+	if err != nil || gid < 0 {
+		return 0, 0, fmt.Errorf("invalid gid in %q: user must be numeric uid:gid", user)
+	}
+	return uid, gid, nil
+}
+
+// Validate checks the profile for values Docker would reject at create time.
+func (p *SecurityProfile) Validate() error {
+	if p.User != "" {
+		if _, _, err := ParseUser(p.User); err != nil {
+			return err
+		}
+	}
+	if p.Seccomp != "" {
+		if _, err := loadSeccomp(p.Seccomp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func applySecurity(cfg *container.Config, hostCfg *container.HostConfig, p *SecurityProfile) error {
+	cfg.User = p.User
+	if p.CapDropAll {
+		hostCfg.CapDrop = []string{"ALL"}
+	}
+	hostCfg.CapAdd = p.CapAdd
+	hostCfg.ReadonlyRootfs = p.ReadOnlyRootfs
+	if len(p.Tmpfs) > 0 {
+		hostCfg.Tmpfs = p.Tmpfs
+	}
+	if p.NoNewPrivileges {
+		hostCfg.SecurityOpt = append(hostCfg.SecurityOpt, "no-new-privileges:true")
+	}
+	if p.Seccomp != "" {
+		// The daemon expects the profile contents, not a path (the CLI reads the file for you)
+		profile, err := loadSeccomp(p.Seccomp)
+		if err != nil {
+			return err
+		}
+		hostCfg.SecurityOpt = append(hostCfg.SecurityOpt, "seccomp="+profile)
+	}
+	return nil
+}
+
+func loadSeccomp(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read seccomp profile: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return "", fmt.Errorf("parse seccomp profile %s: %w", path, err)
+	}
+	return buf.String(), nil
+}
+
+// InspectSecurity reports the security settings a container is actually running with.
+func (c *Client) InspectSecurity(ctx context.Context, id string) (*EffectiveSecurity, error) {
+	resp, err := c.cli.ContainerInspect(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	eff := &EffectiveSecurity{
+		User:           resp.Config.User,
+		Privileged:     resp.HostConfig.Privileged,
+		CapAdd:         resp.HostConfig.CapAdd,
+		CapDrop:        resp.HostConfig.CapDrop,
+		ReadOnlyRootfs: resp.HostConfig.ReadonlyRootfs,
+		Tmpfs:          resp.HostConfig.Tmpfs,
+		Seccomp:        "default",
+	}
+	if eff.CapAdd == nil {
+		eff.CapAdd = []string{}
+	}
+	if eff.CapDrop == nil {
+		eff.CapDrop = []string{}
+	}
+	if eff.Tmpfs == nil {
+		eff.Tmpfs = map[string]string{}
+	}
+	for _, opt := range resp.HostConfig.SecurityOpt {
+		switch {
+		case opt == "no-new-privileges" || opt == "no-new-privileges:true" || opt == "no-new-privileges=true":
+			eff.NoNewPrivileges = true
+		case opt == "seccomp=unconfined" || opt == "seccomp:unconfined":
+			eff.Seccomp = "unconfined"
+		case strings.HasPrefix(opt, "seccomp"):
+			eff.Seccomp = "custom"
+		}
+	}
+	return eff, nil
+}
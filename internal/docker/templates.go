@@ -8,27 +8,51 @@ import (
 )
 
 type GameTemplate struct {
-	ID          string            `json:"id"`
-	Name        string            `json:"name"`
-	Game        string            `json:"game"`
-	Description string            `json:"description"`
-	Image       string            `json:"image"`
-	Ports       []string          `json:"ports"`
-	Env         map[string]string `json:"env"`
-	Volumes     map[string]string `json:"volumes"`
-	Memory      string            `json:"memory"`
-	CPU         float64           `json:"cpu"`
-	ConfigFields []ConfigField    `json:"config_fields"`
+	ID           string            `json:"id"`
+	Version      int               `json:"version,omitempty"` // set by the template store
+	Extends      string            `json:"extends,omitempty"` // ID of the template this one is based on
+	Name         string            `json:"name"`
+	Game         string            `json:"game"`
+	Description  string            `json:"description"`
+	Image        string            `json:"image"`
+	Images       map[string]string `json:"images,omitempty"` // label -> image, selectable on create
+	Ports        []PortMapping     `json:"ports"`
+	Env          map[string]string `json:"env"`
+	Volumes      map[string]string `json:"volumes"`
+	Memory       string            `json:"memory"`
+	CPU          float64           `json:"cpu"`
+	ConfigFields []ConfigField     `json:"config_fields"`
+	Security     *SecurityProfile  `json:"security,omitempty"`
+	Storage      StorageConfig     `json:"storage"`
+	HealthCheck  *HealthCheck      `json:"health_check,omitempty"`
+	Adapter      *AdapterSpec      `json:"adapter,omitempty"` // for games without a built-in adapter
+	Shell        string            `json:"shell,omitempty"`   // for admin shell sessions, default /bin/sh
+	Install      *InstallConfig    `json:"install,omitempty"`
+	Startup      string            `json:"startup,omitempty"` // startup command for images that run $STARTUP
 }
 
 type ConfigField struct {
-	Key         string `json:"key"`
-	Label       string `json:"label"`
-	Type        string `json:"type"` // text, number, select, toggle
-	Default     string `json:"default"`
-	Description string `json:"description"`
+	Key         string   `json:"key"`
+	Label       string   `json:"label"`
+	Type        string   `json:"type"` // text, number, select, toggle
+	Default     string   `json:"default"`
+	Description string   `json:"description"`
 	Options     []string `json:"options,omitempty"`
-	EnvVar      string `json:"env_var"`
+	EnvVar      string   `json:"env_var"`
+	Required    bool     `json:"required,omitempty"`
+	Min         *float64 `json:"min,omitempty"` // number fields only
+	Max         *float64 `json:"max,omitempty"`
+	Pattern     string   `json:"pattern,omitempty"` // regexp the value must match
+
+	// File targets a config file in the server's data instead of, or as well
+	// as, an env var. FileKey is a dotted path for JSON and YAML, "section.key"
+	// for INI and the plain key for properties files.
+	File       string `json:"file,omitempty"`
+	FileKey    string `json:"file_key,omitempty"`
+	FileFormat string `json:"file_format,omitempty"` // properties, json, yaml, ini; default from the extension
+
+	// Remove drops a field inherited through extends.
+	Remove bool `json:"remove,omitempty"`
 }
 
 func LoadTemplates(dir string) ([]GameTemplate, error) {
@@ -47,7 +71,72 @@ func LoadTemplates(dir string) ([]GameTemplate, error) {
 		if err := json.Unmarshal(data, &t); err != nil {
 			return nil, fmt.Errorf("parse template %s: %w", f, err)
 		}
+		// Seccomp profiles are referenced relative to the template directory
+		if t.Security != nil && t.Security.Seccomp != "" && !filepath.IsAbs(t.Security.Seccomp) {
+			t.Security.Seccomp = filepath.Join(dir, t.Security.Seccomp)
+		}
+		// Templates that extend another are validated once resolved
+		if t.Extends == "" {
+			if err := t.Validate(); err != nil {
+				return nil, fmt.Errorf("template %s: %w", f, err)
+			}
+		}
 		templates = append(templates, t)
 	}
 	return templates, nil
 }
+
+// Validate checks a template for missing or inconsistent settings.
+func (t *GameTemplate) Validate() error {
+	if t.ID == "" || t.Name == "" || t.Image == "" {
+		return fmt.Errorf("id, name and image are required")
+	}
+	if t.Security != nil {
+		if err := t.Security.Validate(); err != nil {
+			return fmt.Errorf("security: %w", err)
+		}
+	}
+	if err := t.Storage.Validate(); err != nil {
+		return fmt.Errorf("storage: %w", err)
+	}
+	ports := make([]PortMapping, len(t.Ports))
+	copy(ports, t.Ports)
+	NamePorts(ports)
+	names := make(map[string]bool, len(ports))
+	for _, p := range ports {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate port name %q", p.Name)
+		}
+		names[p.Name] = true
+	}
+	for _, field := range t.ConfigFields {
+		if err := field.Validate(); err != nil {
+			return err
+		}
+	}
+	if t.Install != nil {
+		if err := t.Install.Validate(); err != nil {
+			return err
+		}
+	}
+	if t.HealthCheck != nil {
+		if err := t.HealthCheck.Validate(); err != nil {
+			return fmt.Errorf("health check: %w", err)
+		}
+		if t.HealthCheck.Type != HealthCommand && t.HealthCheck.Port == "" && QueryPort(ports) == nil {
+			return fmt.Errorf("health check: no port given and the template has no query or game port")
+		}
+		if t.HealthCheck.Type == HealthTCP && udpOnly(ports, t.HealthCheck.Port) {
+			return fmt.Errorf("health check: a tcp check can't probe a udp port; use a query check")
+		}
+	}
+	if t.Adapter != nil {
+		if err := t.Adapter.Validate(); err != nil {
+			return fmt.Errorf("adapter: %w", err)
+		}
+	}
+	return nil
+}
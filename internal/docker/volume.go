@@ -0,0 +1,172 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// HelperMount is where helper containers mount the volume they work on.
+const HelperMount = "/data"
+
+// StorageConfig selects where a server's data lives. The default "bind" mode
+// uses a directory under the panel's data dir; "volume" mode uses a Docker
+// named volume created with the given driver and options.
+type StorageConfig struct {
+	Mode       string            `json:"mode,omitempty"` // bind, volume
+	Driver     string            `json:"driver,omitempty"`
+	DriverOpts map[string]string `json:"driver_opts,omitempty"`
+}
+
+// UsesVolume reports whether the storage is a named volume.
+func (s StorageConfig) UsesVolume() bool {
+	return s.Mode == "volume"
+}
+
+// Validate checks the storage mode.
+func (s StorageConfig) Validate() error {
+	switch s.Mode {
+	case "", "bind", "volume":
+		return nil
+	}
+	return fmt.Errorf("unknown storage mode %q (use bind or volume)", s.Mode)
+}
+
+// ServerVolume returns the name of a server's data volume.
+func ServerVolume(serverID string) string {
+	return "reedout-" + serverID + "-data"
+}
+
+// CreateVolume creates a named volume.
+func (c *Client) CreateVolume(ctx context.Context, name string, cfg StorageConfig) error {
+	_, err := c.cli.VolumeCreate(ctx, volume.CreateOptions{
+		Name:       name,
+		Driver:     cfg.Driver,
+		DriverOpts: cfg.DriverOpts,
+		Labels:     map[string]string{"reedout.managed": "true"},
+	})
+	if err != nil {
+		return fmt.Errorf("create volume: %w", err)
+	}
+	return nil
+}
+
+// RemoveVolume deletes a named volume, ignoring volumes that are already gone.
+func (c *Client) RemoveVolume(ctx context.Context, name string) error {
+	if err := c.cli.VolumeRemove(ctx, name, true); err != nil && !client.IsErrNotFound(err) {
+		return fmt.Errorf("remove volume: %w", err)
+	}
+	return nil
+}
+
+// ensureImage pulls an image unless it is already present locally.
+func (c *Client) ensureImage(ctx context.Context, ref string) error {
+	if _, _, err := c.cli.ImageInspectWithRaw(ctx, ref); err == nil {
+		return nil
+	}
+	return c.PullImage(ctx, ref)
+}
+
+// createHelper creates (but does not start) a helper container with the
+// volume mounted at HelperMount.
+func (c *Client) createHelper(ctx context.Context, volumeName string, cmd []string) (string, error) {
+	img := c.HelperImage
+	if img == "" {
+		return "", errors.New("no helper image configured")
+	}
+	if err := c.ensureImage(ctx, img); err != nil {
+		return "", fmt.Errorf("helper image: %w", err)
+	}
+	resp, err := c.cli.ContainerCreate(ctx, &container.Config{
+		Image:  img,
+		Cmd:    cmd,
+		Labels: map[string]string{"reedout.helper": "true"},
+	}, &container.HostConfig{
+		Mounts: []mount.Mount{{Type: mount.TypeVolume, Source: volumeName, Target: HelperMount}},
+	}, nil, nil, "")
+	if err != nil {
+		return "", fmt.Errorf("create helper container: %w", err)
+	}
+	return resp.ID, nil
+}
+
+// RunHelper runs a command in a throwaway container with the volume mounted
+// and returns its stdout. A non-zero exit status is returned as an error
+// carrying stderr.
+func (c *Client) RunHelper(ctx context.Context, volumeName string, cmd ...string) (string, error) {
+	id, err := c.createHelper(ctx, volumeName, cmd)
+	if err != nil {
+		return "", err
+	}
+	defer c.RemoveContainer(context.Background(), id)
+
+	waitCh, errCh := c.cli.ContainerWait(ctx, id, container.WaitConditionNextExit)
+	if err := c.cli.ContainerStart(ctx, id, container.StartOptions{}); err != nil {
+		return "", fmt.Errorf("start helper container: %w", err)
+	}
+
+	var exitCode int64
+	select {
+	case res := <-waitCh:
+		exitCode = res.StatusCode
+	case err := <-errCh:
+		return "", fmt.Errorf("wait for helper container: %w", err)
+	}
+
+	logs, err := c.cli.ContainerLogs(ctx, id, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return "", fmt.Errorf("helper logs: %w", err)
+	}
+	defer logs.Close()
+	var stdout, stderr bytes.Buffer
+	stdcopy.StdCopy(&stdout, &stderr, logs)
+
+	if exitCode != 0 {
+		return stdout.String(), fmt.Errorf("helper exited with %d: %s", exitCode, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return stdout.String(), nil
+}
+
+// CopyFromVolume returns a tar stream of path inside the volume. Entries are
+// rooted at the base name of path, as with `docker cp`.
+func (c *Client) CopyFromVolume(ctx context.Context, volumeName, path string) (io.ReadCloser, error) {
+	id, err := c.createHelper(ctx, volumeName, nil)
+	if err != nil {
+		return nil, err
+	}
+	rc, _, err := c.cli.CopyFromContainer(ctx, id, path)
+	if err != nil {
+		c.RemoveContainer(context.Background(), id)
+		return nil, err
+	}
+	return &helperReader{ReadCloser: rc, cleanup: func() { c.RemoveContainer(context.Background(), id) }}, nil
+}
+
+// CopyToVolume extracts a tar stream into dir inside the volume.
+func (c *Client) CopyToVolume(ctx context.Context, volumeName, dir string, content io.Reader) error {
+	id, err := c.createHelper(ctx, volumeName, nil)
+	if err != nil {
+		return err
+	}
+	defer c.RemoveContainer(context.Background(), id)
+	return c.cli.CopyToContainer(ctx, id, dir, content, container.CopyToContainerOptions{})
+}
+
+type helperReader struct {
+	io.ReadCloser
+	cleanup func()
+}
+
+func (r *helperReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.cleanup()
+	return err
+}
@@ -0,0 +1,416 @@
+// Package egg converts Pterodactyl eggs into ReedOut game templates.
+package egg
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/reedfamily/reedout/internal/docker"
+)
+
+// Egg is the subset of the Pterodactyl egg format (PTDL_v1 and PTDL_v2) that
+// the importer understands.
+type Egg struct {
+	Meta struct {
+		Version string `json:"version"`
+	} `json:"meta"`
+	Name         string            `json:"name"`
+	Author       string            `json:"author"`
+	Description  string            `json:"description"`
+	Features     []string          `json:"features"`
+	DockerImages map[string]string `json:"docker_images"` // PTDL_v2
+	DockerImage  string            `json:"docker_image"`  // PTDL_v1
+	FileDenylist []string          `json:"file_denylist"`
+	Startup      string            `json:"startup"`
+	Config       struct {
+		Files   string `json:"files"`
+		Startup string `json:"startup"`
+		Logs    string `json:"logs"`
+		Stop    string `json:"stop"`
+	} `json:"config"`
+	Scripts struct {
+		Installation struct {
+			Script     string `json:"script"`
+			Container  string `json:"container"`
+			Entrypoint string `json:"entrypoint"`
+		} `json:"installation"`
+	} `json:"scripts"`
+	Variables []Variable `json:"variables"`
+}
+
+// Variable is an egg variable, exposed to the server as an env var.
+type Variable struct {
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	EnvVariable  string `json:"env_variable"`
+	DefaultValue string `json:"default_value"`
+	UserViewable bool   `json:"user_viewable"`
+	UserEditable bool   `json:"user_editable"`
+	Rules        string `json:"rules"`
+	FieldType    string `json:"field_type"`
+}
+
+// Options control parts of the conversion that eggs don't describe.
+type Options struct {
+	ID       string // template ID; derived from the egg name when empty
+	Game     string // game name; defaults to the template ID
+	Port     int    // primary allocation; eggs leave ports to the panel
+	Protocol string // tcp or udp, default tcp
+	Memory   string // default 2G
+}
+
+// Result is a converted template plus everything that could not be carried over.
+type Result struct {
+	Template docker.GameTemplate `json:"template"`
+	Warnings []string            `json:"warnings"`
+}
+
+// dataPath is where Pterodactyl images expect the server files.
+const dataPath = "/home/container"
+
+// containerUser is the uid:gid of the user Pterodactyl images run as.
+const containerUser = "988:988"
+
+// Convert parses an egg and converts it into a template.
+func Convert(data []byte, opts Options) (*Result, error) {
+	var e Egg
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("parse egg: %w", err)
+	}
+	if e.Name == "" {
+		return nil, fmt.Errorf("egg has no name")
+	}
+	switch e.Meta.Version {
+	case "PTDL_v1", "PTDL_v2":
+	default:
+		return nil, fmt.Errorf("unsupported egg format %q", e.Meta.Version)
+	}
+
+	res := &Result{Warnings: []string{}}
+	warn := func(format string, args ...any) {
+		res.Warnings = append(res.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	id := opts.ID
+	if id == "" {
+		id = slug(e.Name)
+	}
+	game := opts.Game
+	if game == "" {
+		game = id
+	}
+	memory := opts.Memory
+	if memory == "" {
+		memory = "2G"
+	}
+
+	t := docker.GameTemplate{
+		ID:           id,
+		Name:         e.Name,
+		Game:         game,
+		Description:  e.Description,
+		Ports:        []docker.PortMapping{},
+		Env:          map[string]string{},
+		Volumes:      map[string]string{"{data_dir}": dataPath},
+		Memory:       memory,
+		CPU:          2.0,
+		ConfigFields: []docker.ConfigField{},
+		Startup:      e.Startup,
+		// Pterodactyl images run as the "container" user; own the data dir to match
+		Security: &docker.SecurityProfile{User: containerUser},
+	}
+
+	// Images: v2 eggs offer a labelled list, v1 a single image
+	switch {
+	case len(e.DockerImages) > 0:
+		labels := make([]string, 0, len(e.DockerImages))
+		for label := range e.DockerImages {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		t.Image = e.DockerImages[labels[0]]
+		if len(labels) > 1 {
+			t.Images = e.DockerImages
+		}
+	case e.DockerImage != "":
+		t.Image = e.DockerImage
+	default:
+		return nil, fmt.Errorf("egg has no docker image")
+	}
+
+	// Ports: Pterodactyl assigns allocations, so the primary one comes from options
+	if opts.Port > 0 {
+		proto := opts.Protocol
+		if proto == "" {
+			proto = "tcp"
+		}
+		port := strconv.Itoa(opts.Port)
+		t.Ports = append(t.Ports, docker.PortMapping{Name: "game", Role: docker.PortGame, Host: port, Container: port, Protocol: proto})
+		t.Env["SERVER_PORT"] = strconv.Itoa(opts.Port)
+	} else {
+		warn("egg does not declare ports; pass a primary port or add ports to the template by hand")
+	}
+
+	for _, v := range e.Variables {
+		if v.EnvVariable == "" {
+			continue
+		}
+		// Extra allocations are usually exposed as *_PORT variables
+		if strings.HasSuffix(v.EnvVariable, "_PORT") && v.EnvVariable != "SERVER_PORT" {
+			if p, err := strconv.Atoi(v.DefaultValue); err == nil && p > 0 {
+				t.Ports = append(t.Ports, docker.PortMapping{
+					Name:      strings.ToLower(strings.TrimSuffix(v.EnvVariable, "_PORT")),
+					Role:      portRole(v.EnvVariable),
+					Host:      v.DefaultValue,
+					Container: v.DefaultValue,
+					Protocol:  "tcp",
+				})
+				warn("variable %s mapped to port %d/tcp; check the protocol", v.EnvVariable, p)
+			}
+		}
+		if !v.UserViewable || !v.UserEditable {
+			// ReedOut has no hidden or read-only fields; keep the value fixed instead
+			t.Env[v.EnvVariable] = v.DefaultValue
+			warn("variable %s is hidden or read-only in the egg and is fixed to its default", v.EnvVariable)
+			continue
+		}
+		field, fieldWarnings := convertVariable(v)
+		for _, w := range fieldWarnings {
+			warn("variable %s: %s", v.EnvVariable, w)
+		}
+		t.ConfigFields = append(t.ConfigFields, field)
+	}
+
+	if s := e.Scripts.Installation; s.Script != "" {
+		image := s.Container
+		if image == "" {
+			image = "ghcr.io/pterodactyl/installers:alpine"
+		}
+		t.Install = &docker.InstallConfig{
+			Image:      image,
+			Script:     strings.ReplaceAll(s.Script, "\r\n", "\n"),
+			Entrypoint: s.Entrypoint,
+		}
+	}
+
+	if hasConfig(e.Config.Files) {
+		warn("config file parsers (config.files) are not supported; edit the generated files by hand")
+	}
+	if hasConfig(e.Config.Startup) {
+		warn("startup detection (config.startup) is not supported; add a health_check instead")
+	}
+	if hasConfig(e.Config.Logs) {
+		warn("custom log settings (config.logs) are ignored")
+	}
+	if e.Config.Stop != "" && e.Config.Stop != "^C" {
+		warn("stop command %q is not supported; the container is stopped with SIGTERM", e.Config.Stop)
+	}
+	for _, f := range e.Features {
+		warn("egg feature %q is not supported", f)
+	}
+	if len(e.FileDenylist) > 0 {
+		warn("file denylist is not supported")
+	}
+
+	res.Template = t
+	return res, nil
+}
+
+// Save writes the converted template into a template directory as
+// <id>.json. It fails with os.ErrExist rather than overwrite a template.
+func (r *Result) Save(dir string) (string, error) {
+	data, err := json.MarshalIndent(r.Template, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, r.Template.ID+".json")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	_, err = f.Write(append(data, '\n'))
+	return path, err
+}
+
+// convertVariable maps an egg variable and its Laravel validation rules to a
+// config field. Rules with no equivalent are returned as warnings.
+func convertVariable(v Variable) (docker.ConfigField, []string) {
+	f := docker.ConfigField{
+		Key:         strings.ToLower(v.EnvVariable),
+		Label:       v.Name,
+		Type:        "text",
+		Default:     v.DefaultValue,
+		Description: v.Description,
+		EnvVar:      v.EnvVariable,
+	}
+	var warnings []string
+	var minLen, maxLen *float64
+
+	for _, rule := range splitRules(v.Rules) {
+		name, arg, _ := strings.Cut(rule, ":")
+		switch name {
+		case "required":
+			f.Required = true
+		case "nullable", "sometimes", "string", "present":
+		case "integer", "numeric":
+			f.Type = "number"
+		case "boolean":
+			f.Type = "toggle"
+		case "in":
+			f.Type = "select"
+			f.Options = strings.Split(arg, ",")
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("invalid rule %q", rule))
+				continue
+			}
+			if name == "min" {
+				minLen = &n
+			} else {
+				maxLen = &n
+			}
+		case "between":
+			lo, hi, _ := strings.Cut(arg, ",")
+			a, errA := strconv.ParseFloat(lo, 64)
+			b, errB := strconv.ParseFloat(hi, 64)
+			if errA != nil || errB != nil {
+				warnings = append(warnings, fmt.Sprintf("invalid rule %q", rule))
+				continue
+			}
+			minLen, maxLen = &a, &b
+		case "regex":
+			pattern, err := convertRegex(arg)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("regex rule dropped: %v", err))
+				continue
+			}
+			f.Pattern = pattern
+		case "alpha_num":
+			f.Pattern = "^[a-zA-Z0-9]*$"
+		case "alpha_dash":
+			f.Pattern = "^[a-zA-Z0-9_-]*$"
+		case "url":
+			f.Pattern = "^https?://"
+		default:
+			warnings = append(warnings, fmt.Sprintf("rule %q is not supported", rule))
+		}
+	}
+
+	// min/max bound the value of numbers but the length of strings
+	if f.Type == "number" {
+		f.Min, f.Max = minLen, maxLen
+	} else if (minLen != nil || maxLen != nil) && f.Pattern == "" && f.Type == "text" {
+		lo, hi := "0", ""
+		if minLen != nil {
+			lo = strconv.Itoa(int(*minLen))
+		}
+		if maxLen != nil {
+			hi = strconv.Itoa(int(*maxLen))
+		}
+		f.Pattern = fmt.Sprintf("^(?s:.{%s,%s})$", lo, hi)
+	}
+
+	// Eggs often ship defaults their own rules reject; ReedOut refuses those
+	if err := f.Validate(); err != nil {
+		warnings = append(warnings, fmt.Sprintf("constraints dropped: %v", err))
+		f.Min, f.Max, f.Pattern = nil, nil, ""
+		if f.Type == "select" && !slices.Contains(f.Options, f.Default) {
+			f.Options = append(f.Options, f.Default)
+		}
+	}
+	return f, warnings
+}
+
+// splitRules splits a rules string like "required|string|max:20". A regex
+// rule may itself contain pipes, so it runs to its closing delimiter.
+func splitRules(rules string) []string {
+	var out []string
+	for rules != "" {
+		end := strings.Index(rules, "|")
+		if strings.HasPrefix(rules, "regex:/") {
+			if close := regexEnd(rules[len("regex:"):]); close > 0 {
+				n := len("regex:") + close
+				end = strings.Index(rules[n:], "|")
+				if end >= 0 {
+					end += n
+				}
+			}
+		}
+		rule, rest := rules, ""
+		if end >= 0 {
+			rule, rest = rules[:end], rules[end+1:]
+		}
+		if rule = strings.TrimSpace(rule); rule != "" {
+			out = append(out, rule)
+		}
+		rules = rest
+	}
+	return out
+}
+
+// regexEnd returns the index of the closing delimiter of a /pattern/ regex,
+// or -1 if it has none.
+func regexEnd(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '/':
+			return i
+		}
+	}
+	return -1
+}
+
+// convertRegex turns a PHP-style /pattern/flags regex into a Go regexp.
+func convertRegex(s string) (string, error) {
+	if len(s) < 2 || s[0] != '/' {
+		return "", fmt.Errorf("unrecognised regex %q", s)
+	}
+	end := strings.LastIndex(s, "/")
+	if end == 0 {
+		return "", fmt.Errorf("unterminated regex %q", s)
+	}
+	pattern, flags := s[1:end], s[end+1:]
+	if strings.Contains(flags, "i") {
+		pattern = "(?i)" + pattern
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return "", err
+	}
+	return pattern, nil
+}
+
+// hasConfig reports whether an egg config section (a JSON string) has content.
+func hasConfig(s string) bool {
+	s = strings.TrimSpace(s)
+	return s != "" && s != "{}" && s != "[]"
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+func slug(name string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// portRole guesses a port's role from its variable name.
+func portRole(envVar string) string {
+	switch {
+	case strings.Contains(envVar, "QUERY"):
+		return docker.PortQuery
+	case strings.Contains(envVar, "RCON"):
+		return docker.PortRCON
+	case strings.Contains(envVar, "WEB"), strings.Contains(envVar, "HTTP"):
+		return docker.PortWeb
+	}
+	return ""
+}
@@ -0,0 +1,255 @@
+// Package a2s implements the Steam server query protocol (A2S_INFO,
+// A2S_PLAYER and A2S_RULES) used by Source and most Steam game servers.
+package a2s
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	headerSingle int32 = -1
+	headerSplit  int32 = -2
+
+	requestInfo    = 0x54
+	requestPlayer  = 0x55
+	requestRules   = 0x56
+	responseInfo   = 0x49
+	responsePlayer = 0x44
+	responseRules  = 0x45
+	challenge      = 0x41
+
+	defaultTimeout = 3 * time.Second
+	// maxDatagram is generous; servers split responses at about 1400 bytes.
+	maxDatagram = 4096
+	// maxSplits and maxDecompressed bound what a server can make us buffer.
+	maxSplits       = 64
+	maxDecompressed = 1 << 20
+)
+
+// Info is the answer to A2S_INFO.
+type Info struct {
+	Protocol    byte   `json:"protocol"`
+	Name        string `json:"name"`
+	Map         string `json:"map"`
+	Folder      string `json:"folder"`
+	Game        string `json:"game"`
+	AppID       uint16 `json:"app_id"`
+	Players     int    `json:"players"`
+	MaxPlayers  int    `json:"max_players"`
+	Bots        int    `json:"bots"`
+	ServerType  string `json:"server_type"` // d (dedicated), l (listen), p (proxy)
+	Environment string `json:"environment"` // l (Linux), w (Windows), m (macOS)
+	Password    bool   `json:"password"`
+	VAC         bool   `json:"vac"`
+	Version     string `json:"version"`
+	Port        uint16 `json:"port,omitempty"`
+	SteamID     uint64 `json:"steam_id,omitempty"`
+	Keywords    string `json:"keywords,omitempty"`
+	GameID      uint64 `json:"game_id,omitempty"`
+}
+
+// Player is one entry of the answer to A2S_PLAYER.
+type Player struct {
+	Name     string        `json:"name"`
+	Score    int32         `json:"score"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Client queries one server. A zero Timeout means three seconds per request.
+type Client struct {
+	Addr    string
+	Timeout time.Duration
+}
+
+// Info sends A2S_INFO.
+func (c *Client) Info(ctx context.Context) (*Info, error) {
+	payload := append([]byte{requestInfo}, "Source Engine Query\x00"...)
+	data, err := c.query(ctx, payload, responseInfo, false)
+	if err != nil {
+		return nil, err
+	}
+	return parseInfo(data)
+}
+
+// Players sends A2S_PLAYER.
+func (c *Client) Players(ctx context.Context) ([]Player, error) {
+	data, err := c.query(ctx, []byte{requestPlayer}, responsePlayer, true)
+	if err != nil {
+		return nil, err
+	}
+	return parsePlayers(data)
+}
+
+// Rules sends A2S_RULES and returns the server's console variables.
+func (c *Client) Rules(ctx context.Context) (map[string]string, error) {
+	data, err := c.query(ctx, []byte{requestRules}, responseRules, true)
+	if err != nil {
+		return nil, err
+	}
+	return parseRules(data)
+}
+
+// query sends a request, answering a challenge if the server sends one, and
+// returns the response body after its type byte. Player and rules requests
+// always carry a challenge, starting with -1 to ask for one.
+func (c *Client) query(ctx context.Context, payload []byte, want byte, needsChallenge bool) ([]byte, error) {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", c.Addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	req := payload
+	if needsChallenge {
+		req = append(append([]byte{}, payload...), 0xff, 0xff, 0xff, 0xff)
+	}
+	// A server may challenge more than once, e.g. after its challenge expires
+	for range 3 {
+		if err := send(conn, req); err != nil {
+			return nil, err
+		}
+		data, err := receive(conn)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) == 0 {
+			return nil, errors.New("a2s: empty response")
+		}
+		switch data[0] {
+		case want:
+			return data[1:], nil
+		case challenge:
+			if len(data) < 5 {
+				return nil, errors.New("a2s: short challenge")
+			}
+			req = append(append([]byte{}, payload...), data[1:5]...)
+		default:
+			return nil, fmt.Errorf("a2s: unexpected response type 0x%02x", data[0])
+		}
+	}
+	return nil, errors.New("a2s: server kept sending challenges")
+}
+
+func send(conn net.Conn, payload []byte) error {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, headerSingle)
+	buf.Write(payload)
+	_, err := conn.Write(buf.Bytes())
+	return err
+}
+
+// receive reads one response, reassembling split packets. It returns the
+// payload after the -1 header.
+func receive(conn net.Conn) ([]byte, error) {
+	pkt, err := readPacket(conn)
+	if err != nil {
+		return nil, err
+	}
+	switch int32(binary.LittleEndian.Uint32(pkt)) {
+	case headerSingle:
+		return pkt[4:], nil
+	case headerSplit:
+	default:
+		return nil, errors.New("a2s: invalid packet header")
+	}
+
+	// Source split packets: id, total, number, max size. Compressed responses
+	// (id with the high bit set) also carry the decompressed size and CRC32
+	// in the first part.
+	var id int32
+	var parts [][]byte
+	var total, received int
+	var compressed bool
+	var size, crc uint32
+	for {
+		r := bytes.NewReader(pkt[4:])
+		var h struct {
+			ID     int32
+			Total  byte
+			Number byte
+			Size   uint16
+		}
+		if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+			return nil, errors.New("a2s: short split packet")
+		}
+		if parts == nil {
+			if h.Total == 0 || h.Total > maxSplits {
+				return nil, fmt.Errorf("a2s: invalid split count %d", h.Total)
+			}
+			id, total = h.ID, int(h.Total)
+			parts = make([][]byte, total)
+			compressed = uint32(h.ID)&0x80000000 != 0
+		}
+		if h.ID == id && int(h.Number) < total && parts[h.Number] == nil {
+			if h.Number == 0 && compressed {
+				if binary.Read(r, binary.LittleEndian, &size) != nil || binary.Read(r, binary.LittleEndian, &crc) != nil {
+					return nil, errors.New("a2s: short compressed header")
+				}
+			}
+			parts[h.Number] = pkt[len(pkt)-r.Len():]
+			received++
+		}
+		if received == total {
+			break
+		}
+
+		if pkt, err = readPacket(conn); err != nil {
+			return nil, err
+		}
+		if int32(binary.LittleEndian.Uint32(pkt)) != headerSplit {
+			return nil, errors.New("a2s: unexpected packet in split response")
+		}
+	}
+
+	data := bytes.Join(parts, nil)
+	if compressed {
+		if size > maxDecompressed {
+			return nil, fmt.Errorf("a2s: compressed response too large (%d bytes)", size)
+		}
+		out, err := io.ReadAll(io.LimitReader(bzip2.NewReader(bytes.NewReader(data)), int64(size)+1))
+		if err != nil {
+			return nil, fmt.Errorf("a2s: decompress: %w", err)
+		}
+		if uint32(len(out)) != size || crc32.ChecksumIEEE(out) != crc {
+			return nil, errors.New("a2s: compressed response failed its checksum")
+		}
+		data = out
+	}
+	if len(data) < 4 || int32(binary.LittleEndian.Uint32(data)) != headerSingle {
+		return nil, errors.New("a2s: invalid reassembled response")
+	}
+	return data[4:], nil
+}
+
+// readPacket reads one datagram into its own buffer.
+func readPacket(conn net.Conn) ([]byte, error) {
+	buf := make([]byte, maxDatagram)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	if n < 4 {
+		return nil, errors.New("a2s: short packet")
+	}
+	return buf[:n], nil
+}
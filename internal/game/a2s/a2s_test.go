@@ -0,0 +1,268 @@
+package a2s
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"hash/crc32"
+	"math"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testChallenge = []byte{0x01, 0x02, 0x03, 0x04}
+
+// respond starts a UDP server that answers each request datagram with the
+// datagrams respond returns.
+func respond(t *testing.T, respond func(req []byte) [][]byte) string {
+	t.Helper()
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, maxDatagram)
+		for {
+			n, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			for _, pkt := range respond(append([]byte{}, buf[:n]...)) {
+				conn.WriteToUDP(pkt, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// single wraps a payload in the -1 header of an unsplit response.
+func single(payload []byte) []byte {
+	return append([]byte{0xff, 0xff, 0xff, 0xff}, payload...)
+}
+
+// split cuts a response into Source split packets of at most size bytes
+// each. Compressed responses carry the uncompressed size and CRC32 of plain
+// in their first part.
+func split(id uint32, data []byte, size int, plain []byte) [][]byte {
+	var chunks [][]byte
+	for len(data) > size {
+		chunks = append(chunks, data[:size])
+		data = data[size:]
+	}
+	chunks = append(chunks, data)
+
+	var pkts [][]byte
+	for i, chunk := range chunks {
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.LittleEndian, headerSplit)
+		binary.Write(&buf, binary.LittleEndian, id)
+		buf.WriteByte(byte(len(chunks)))
+		buf.WriteByte(byte(i))
+		binary.Write(&buf, binary.LittleEndian, uint16(1248))
+		if i == 0 && plain != nil {
+			binary.Write(&buf, binary.LittleEndian, uint32(len(plain)))
+			binary.Write(&buf, binary.LittleEndian, crc32.ChecksumIEEE(plain))
+		}
+		buf.Write(chunk)
+		pkts = append(pkts, buf.Bytes())
+	}
+	return pkts
+}
+
+// challenged answers a request with a challenge until it carries
+// testChallenge, then with answer.
+func challenged(answer []byte) func(req []byte) [][]byte {
+	return func(req []byte) [][]byte {
+		if !bytes.HasSuffix(req, testChallenge) {
+			return [][]byte{single(append([]byte{challenge}, testChallenge...))}
+		}
+		return [][]byte{single(answer)}
+	}
+}
+
+func reversed(pkts [][]byte) [][]byte {
+	out := make([][]byte, 0, len(pkts))
+	for i := len(pkts) - 1; i >= 0; i-- {
+		out = append(out, pkts[i])
+	}
+	return out
+}
+
+func infoPayload() []byte {
+	var b bytes.Buffer
+	b.WriteByte(responseInfo)
+	b.WriteByte(17)
+	b.WriteString("ReedOut TF2\x00ctf_2fort\x00tf\x00Team Fortress\x00")
+	binary.Write(&b, binary.LittleEndian, uint16(440))
+	b.Write([]byte{3, 24, 0, 'd', 'l', 0, 1})
+	b.WriteString("8622567\x00")
+	b.WriteByte(edfPort)
+	binary.Write(&b, binary.LittleEndian, uint16(27015))
+	return b.Bytes()
+}
+
+var wantInfo = &Info{
+	Protocol:    17,
+	Name:        "ReedOut TF2",
+	Map:         "ctf_2fort",
+	Folder:      "tf",
+	Game:        "Team Fortress",
+	AppID:       440,
+	Players:     3,
+	MaxPlayers:  24,
+	ServerType:  "d",
+	Environment: "l",
+	VAC:         true,
+	Version:     "8622567",
+	Port:        27015,
+}
+
+func playersPayload() []byte {
+	var b bytes.Buffer
+	b.WriteByte(responsePlayer)
+	b.WriteByte(2)
+	for _, p := range []struct {
+		name  string
+		score int32
+		secs  float32
+	}{{"alice", 5, 61.5}, {"bob", 2, 30}} {
+		b.WriteByte(0)
+		b.WriteString(p.name + "\x00")
+		binary.Write(&b, binary.LittleEndian, p.score)
+		binary.Write(&b, binary.LittleEndian, math.Float32bits(p.secs))
+	}
+	return b.Bytes()
+}
+
+var wantPlayers = []Player{
+	{Name: "alice", Score: 5, Duration: 61500 * time.Millisecond},
+	{Name: "bob", Score: 2, Duration: 30 * time.Second},
+}
+
+func rulesPayload() []byte {
+	return append([]byte{responseRules, 2, 0}, "mp_timelimit\x0030\x00sv_gravity\x00800\x00"...)
+}
+
+var wantRules = map[string]string{"mp_timelimit": "30", "sv_gravity": "800"}
+
+// compressedRules is single(rulesPayload()) compressed with bzip2.
+var compressedRules, _ = hex.DecodeString("425a68393141592653596ab869400000134f80d000484002000000a2a65d200000a0002211a0d003ca14c269a034c4e8329a3d62eea7c06b41a9d096908b3c09b85a7c5dc914e14241aae1a500")
+
+func TestQuery(t *testing.T) {
+	info := func(c *Client) (any, error) { return c.Info(context.Background()) }
+	players := func(c *Client) (any, error) { return c.Players(context.Background()) }
+	rules := func(c *Client) (any, error) { return c.Rules(context.Background()) }
+
+	tests := []struct {
+		name    string
+		respond func(req []byte) [][]byte
+		query   func(c *Client) (any, error)
+		want    any
+		wantErr string
+	}{
+		{
+			name: "info without challenge",
+			respond: func([]byte) [][]byte {
+				return [][]byte{single(infoPayload())}
+			},
+			query: info,
+			want:  wantInfo,
+		},
+		{
+			name:    "info with challenge",
+			respond: challenged(infoPayload()),
+			query:   info,
+			want:    wantInfo,
+		},
+		{
+			name:    "players with challenge",
+			respond: challenged(playersPayload()),
+			query:   players,
+			want:    wantPlayers,
+		},
+		{
+			name:    "rules with challenge",
+			respond: challenged(rulesPayload()),
+			query:   rules,
+			want:    wantRules,
+		},
+		{
+			name: "split response",
+			respond: func(req []byte) [][]byte {
+				if !bytes.HasSuffix(req, testChallenge) {
+					return [][]byte{single(append([]byte{challenge}, testChallenge...))}
+				}
+				return split(7, single(rulesPayload()), 10, nil)
+			},
+			query: rules,
+			want:  wantRules,
+		},
+		{
+			name: "split response out of order",
+			respond: func([]byte) [][]byte {
+				return reversed(split(7, single(infoPayload()), 16, nil))
+			},
+			query: info,
+			want:  wantInfo,
+		},
+		{
+			name: "compressed split response",
+			respond: func(req []byte) [][]byte {
+				if !bytes.HasSuffix(req, testChallenge) {
+					return [][]byte{single(append([]byte{challenge}, testChallenge...))}
+				}
+				return reversed(split(0x80000007, compressedRules, 40, single(rulesPayload())))
+			},
+			query: rules,
+			want:  wantRules,
+		},
+		{
+			name: "compressed response with a bad checksum",
+			respond: func([]byte) [][]byte {
+				return split(0x80000007, compressedRules, 40, single(infoPayload()))
+			},
+			query:   rules,
+			wantErr: "checksum",
+		},
+		{
+			name: "server keeps challenging",
+			respond: func([]byte) [][]byte {
+				return [][]byte{single([]byte{challenge, 9, 9, 9, 9})}
+			},
+			query:   players,
+			wantErr: "kept sending challenges",
+		},
+		{
+			name: "unexpected response type",
+			respond: func([]byte) [][]byte {
+				return [][]byte{single(playersPayload())}
+			},
+			query:   info,
+			wantErr: "unexpected response type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Client{Addr: respond(t, tt.respond), Timeout: time.Second}
+			got, err := tt.query(c)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
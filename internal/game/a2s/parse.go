@@ -0,0 +1,157 @@
+package a2s
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+var errShort = errors.New("a2s: truncated response")
+
+// reader reads the little-endian fields and NUL-terminated strings of a
+// response, remembering the first error.
+type reader struct {
+	b   []byte
+	err error
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil || len(r.b) < 1 {
+		r.err = errShort
+		return 0
+	}
+	v := r.b[0]
+	r.b = r.b[1:]
+	return v
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil || len(r.b) < n {
+		r.err = errShort
+		return make([]byte, n)
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *reader) uint16() uint16 { return binary.LittleEndian.Uint16(r.bytes(2)) }
+func (r *reader) uint32() uint32 { return binary.LittleEndian.Uint32(r.bytes(4)) }
+func (r *reader) uint64() uint64 { return binary.LittleEndian.Uint64(r.bytes(8)) }
+
+func (r *reader) string() string {
+	if r.err != nil {
+		return ""
+	}
+	i := bytes.IndexByte(r.b, 0)
+	if i < 0 {
+		r.err = errShort
+		return ""
+	}
+	v := string(r.b[:i])
+	r.b = r.b[i+1:]
+	return v
+}
+
+// Extra data flags of A2S_INFO.
+const (
+	edfPort     = 0x80
+	edfSteamID  = 0x10
+	edfSourceTV = 0x40
+	edfKeywords = 0x20
+	edfGameID   = 0x01
+)
+
+func parseInfo(data []byte) (*Info, error) {
+	r := &reader{b: data}
+	info := &Info{
+		Protocol: r.byte(),
+		Name:     r.string(),
+		Map:      r.string(),
+		Folder:   r.string(),
+		Game:     r.string(),
+		AppID:    r.uint16(),
+	}
+	info.Players = int(r.byte())
+	info.MaxPlayers = int(r.byte())
+	info.Bots = int(r.byte())
+	info.ServerType = string(rune(r.byte()))
+	info.Environment = string(rune(r.byte()))
+	info.Password = r.byte() == 1
+	info.VAC = r.byte() == 1
+	if info.AppID == 2400 {
+		// The Ship adds mode, witnesses and duration
+		r.bytes(3)
+	}
+	info.Version = r.string()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	// Extra data is optional
+	if len(r.b) == 0 {
+		return info, nil
+	}
+	edf := r.byte()
+	if edf&edfPort != 0 {
+		info.Port = r.uint16()
+	}
+	if edf&edfSteamID != 0 {
+		info.SteamID = r.uint64()
+	}
+	if edf&edfSourceTV != 0 {
+		r.uint16()
+		r.string()
+	}
+	if edf&edfKeywords != 0 {
+		info.Keywords = r.string()
+	}
+	if edf&edfGameID != 0 {
+		info.GameID = r.uint64()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return info, nil
+}
+
+func parsePlayers(data []byte) ([]Player, error) {
+	r := &reader{b: data}
+	count := int(r.byte())
+	players := make([]Player, 0, count)
+	for range count {
+		r.byte() // index, always 0 on most servers
+		p := Player{Name: r.string(), Score: int32(r.uint32())}
+		secs := math.Float32frombits(r.uint32())
+		if r.err != nil {
+			// Some servers report more players than they list
+			if len(players) > 0 {
+				break
+			}
+			return nil, r.err
+		}
+		p.Duration = time.Duration(float64(secs) * float64(time.Second))
+		players = append(players, p)
+	}
+	return players, nil
+}
+
+func parseRules(data []byte) (map[string]string, error) {
+	r := &reader{b: data}
+	count := int(r.uint16())
+	rules := make(map[string]string, count)
+	for range count {
+		name, value := r.string(), r.string()
+		if r.err != nil {
+			// Large rule lists are sometimes cut off
+			if len(rules) > 0 {
+				break
+			}
+			return nil, r.err
+		}
+		rules[name] = value
+	}
+	return rules, nil
+}
@@ -1,5 +1,14 @@
 package game
 
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/reedfamily/reedout/internal/storage"
+)
+
 // GameAdapter provides game-specific behavior for a server type.
 type GameAdapter interface {
 	// Game returns the game identifier (e.g., "minecraft", "vintagestory")
@@ -16,7 +25,168 @@ type GameAdapter interface {
 }
 
 type LogEvent struct {
-	Type    string // "player_join", "player_leave", "chat", "info", "error"
-	Player  string
-	Message string
+	Type    string `json:"type"` // "player_join", "player_leave", "chat", "info", "error"
+	Player  string `json:"player,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// LogSession is implemented by adapters whose log lines depend on earlier
+// ones, such as a leave naming only the connection a join line introduced.
+// NewLogParser returns a parser with its own state for one server's log.
+type LogSession interface {
+	NewLogParser() func(line string) *LogEvent
+}
+
+// LogParser returns a parser for one server's log: a new one for adapters
+// implementing LogSession, ParseLogLine for the rest.
+func LogParser(adapter GameAdapter) func(line string) *LogEvent {
+	if s, ok := adapter.(LogSession); ok {
+		return s.NewLogParser()
+	}
+	return adapter.ParseLogLine
+}
+
+// Endpoint is how the panel reaches a running server.
+type Endpoint struct {
+	IP    string            // container IP on the server's network
+	Ports map[string]string // port role -> container port
+	Env   map[string]string // container environment, e.g. for passwords
+	Files storage.Volume    // the server's data, e.g. for generated passwords
+
+	// Console writes a line to the server's stdin, for games that only take
+	// commands there. Output goes to the logs.
+	Console func(ctx context.Context, line string) error
+}
+
+// Commander is implemented by adapters that can run console commands over a
+// remote protocol and return their output.
+type Commander interface {
+	Command(ctx context.Context, ep Endpoint, command string) (string, error)
+}
+
+// Prober is implemented by adapters that can check whether a server is
+// accepting players, e.g. by running a status query against addr (host:port).
+type Prober interface {
+	Probe(ctx context.Context, addr string) error
+}
+
+// Status is what a server reports about itself to status queries.
+type Status struct {
+	Version   string    `json:"version,omitempty"`
+	MOTD      string    `json:"motd,omitempty"`
+	Online    int       `json:"online"`
+	Max       int       `json:"max"`
+	Players   []string  `json:"players,omitempty"` // sample of online players, if the game sends one
+	Latency   int64     `json:"latency_ms"`
+	Error     string    `json:"error,omitempty"` // set when the last query failed
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// StatusQuerier is implemented by adapters that can query a server's
+// status, such as version and player counts, at addr (host:port).
+type StatusQuerier interface {
+	Status(ctx context.Context, addr string) (*Status, error)
+}
+
+// EndpointStatusQuerier is implemented by adapters whose status queries need
+// more than an address, such as an API token from the server's environment.
+// Only IP, Ports and Env are set on ep.
+type EndpointStatusQuerier interface {
+	EndpointStatus(ctx context.Context, ep Endpoint) (*Status, error)
+}
+
+// PlayerManager is implemented by adapters that can moderate players on a
+// running server.
+type PlayerManager interface {
+	Kick(ctx context.Context, ep Endpoint, player, reason string) error
+	Ban(ctx context.Context, ep Endpoint, player, reason string) error
+	Unban(ctx context.Context, ep Endpoint, player string) error
+}
+
+// SaveController is implemented by adapters that can make a running server
+// write its world to disk.
+type SaveController interface {
+	Save(ctx context.Context, ep Endpoint) error
+}
+
+// SaveCommander is implemented by adapters whose servers save the world
+// when a console command is written to their stdin. An empty command means
+// the game has none.
+type SaveCommander interface {
+	SaveCommand() string
+}
+
+// Broadcaster is implemented by adapters that can send a message to every
+// player on a running server.
+type Broadcaster interface {
+	Broadcast(ctx context.Context, ep Endpoint, message string) error
+}
+
+// ErrNotAvailable is returned by optional features that depend on how a
+// server is set up, such as a mod's remote API, when this server lacks it.
+// Commanders return it to fall back to the console.
+var ErrNotAvailable = errors.New("not available on this server")
+
+// ConfigFile is a game config file, relative to the server's data directory.
+type ConfigFile struct {
+	Path        string `json:"path"`
+	Format      string `json:"format"` // properties, ini, json, yaml or text
+	Description string `json:"description,omitempty"`
+}
+
+// ConfigSchema is implemented by adapters that know which config files their
+// game reads, so the UI can offer them for editing.
+type ConfigSchema interface {
+	ConfigFiles() []ConfigFile
+}
+
+// Errors world managers and player lists return.
+var (
+	ErrUnknownWorld = errors.New("world not found")
+	ErrWorldExists  = errors.New("world already exists")
+	ErrUnknownList  = errors.New("unknown player list")
+)
+
+// World is a saved world or map in a server's data.
+type World struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	ModTime string `json:"mod_time"`
+	Active  bool   `json:"active"` // loaded on the next start
+}
+
+// WorldManager is implemented by adapters that can list a server's worlds
+// and choose the one it loads. Worlds gets the server's config values;
+// SelectWorld returns the config values, by env var or key, that make the
+// server load a world on its next start.
+type WorldManager interface {
+	Worlds(ctx context.Context, files storage.Volume, config map[string]string) ([]World, error)
+	SelectWorld(ctx context.Context, files storage.Volume, name string) (map[string]string, error)
+}
+
+// WorldCreator is implemented by adapters that can set up a new world,
+// generated on the server's next start from game-specific settings. Like
+// SelectWorld it returns the config values that make the server load it.
+type WorldCreator interface {
+	CreateWorld(ctx context.Context, files storage.Volume, name string, settings json.RawMessage) (map[string]string, error)
+}
+
+// ListEntry is a player on a list such as admins or bans. Which fields a
+// list uses depends on the game.
+type ListEntry struct {
+	Name   string `json:"name,omitempty"`
+	ID     string `json:"id,omitempty"`     // e.g. a Steam ID, XUID, UUID or IP address
+	Level  string `json:"level,omitempty"`  // e.g. an operator level or permission
+	Reason string `json:"reason,omitempty"` // for bans
+}
+
+// PlayerLists is implemented by adapters that manage lists of players in a
+// server's data, such as admins, bans and allowlists. ep is set while the
+// server is running so changes can be applied live, and nil otherwise.
+// Entries are removed by ID or name.
+type PlayerLists interface {
+	PlayerLists() []string
+	ReadPlayerList(ctx context.Context, files storage.Volume, list string) ([]ListEntry, error)
+	AddToPlayerList(ctx context.Context, files storage.Volume, ep *Endpoint, list string, entry ListEntry) error
+	RemoveFromPlayerList(ctx context.Context, files storage.Volume, ep *Endpoint, list, player string) error
 }
@@ -0,0 +1,60 @@
+// Package bedrock is the adapter for Minecraft Bedrock Dedicated Server, run
+// from the itzg/minecraft-bedrock-server image. Bedrock has no RCON, so
+// commands go to the server's console.
+package bedrock
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/reedfamily/reedout/internal/game"
+)
+
+func init() {
+	game.Register(&Adapter{})
+}
+
+type Adapter struct{}
+
+var (
+	// [2024-01-15 10:00:00:123 INFO] Player connected: Steve, xuid: 2535412345678901
+	joinRe  = regexp.MustCompile(`Player connected: (.+?), xuid:`)
+	leaveRe = regexp.MustCompile(`Player disconnected: (.+?), xuid:`)
+)
+
+func (a *Adapter) Game() string { return "bedrock" }
+
+func (a *Adapter) ParseLogLine(line string) *game.LogEvent {
+	if m := joinRe.FindStringSubmatch(line); m != nil {
+		return &game.LogEvent{Type: "player_join", Player: m[1]}
+	}
+	if m := leaveRe.FindStringSubmatch(line); m != nil {
+		return &game.LogEvent{Type: "player_leave", Player: m[1]}
+	}
+	if strings.Contains(line, " ERROR]") {
+		return &game.LogEvent{Type: "error", Message: line}
+	}
+	return nil
+}
+
+func (a *Adapter) PlayerCommand() string { return "list" }
+func (a *Adapter) StopCommand() string   { return "stop" }
+
+// Probe reports whether the server answers a RakNet ping.
+func (a *Adapter) Probe(ctx context.Context, addr string) error {
+	_, err := Ping(ctx, addr)
+	return err
+}
+
+func (a *Adapter) Status(ctx context.Context, addr string) (*game.Status, error) {
+	return Ping(ctx, addr)
+}
+
+func (a *Adapter) ConfigFiles() []game.ConfigFile {
+	return []game.ConfigFile{
+		{Path: "server.properties", Format: "properties", Description: "Server settings"},
+		{Path: "allowlist.json", Format: "json", Description: "Players allowed to join when the allowlist is on"},
+		{Path: "permissions.json", Format: "json", Description: "Operator, member and visitor permissions by XUID"},
+	}
+}
@@ -0,0 +1,198 @@
+package bedrock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/storage"
+)
+
+const (
+	allowlistFile   = "allowlist.json"
+	permissionsFile = "permissions.json"
+)
+
+var (
+	gamertag = regexp.MustCompile(`^[A-Za-z0-9 _-]{1,32}$`)
+	xuid     = regexp.MustCompile(`^[0-9]{1,20}$`)
+)
+
+// allowed is an entry of allowlist.json. The server fills in the XUID when
+// the player first joins.
+type allowed struct {
+	IgnoresPlayerLimit bool   `json:"ignoresPlayerLimit"`
+	Name               string `json:"name"`
+	XUID               string `json:"xuid,omitempty"`
+}
+
+// permission is an entry of permissions.json.
+type permission struct {
+	Permission string `json:"permission"` // visitor, member or operator
+	XUID       string `json:"xuid"`
+}
+
+func (a *Adapter) PlayerLists() []string {
+	return []string{"allowlist", "permissions"}
+}
+
+func (a *Adapter) ReadPlayerList(ctx context.Context, files storage.Volume, list string) ([]game.ListEntry, error) {
+	entries := []game.ListEntry{}
+	switch list {
+	case "allowlist":
+		var players []allowed
+		if err := readJSON(ctx, files, allowlistFile, &players); err != nil {
+			return nil, err
+		}
+		for _, p := range players {
+			entries = append(entries, game.ListEntry{Name: p.Name, ID: p.XUID})
+		}
+	case "permissions":
+		var perms []permission
+		if err := readJSON(ctx, files, permissionsFile, &perms); err != nil {
+			return nil, err
+		}
+		for _, p := range perms {
+			entries = append(entries, game.ListEntry{ID: p.XUID, Level: p.Permission})
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", game.ErrUnknownList, list)
+	}
+	return entries, nil
+}
+
+// AddToPlayerList adds a gamertag to the allowlist, or sets the permission
+// level of an XUID (default operator). A running server reloads the file.
+func (a *Adapter) AddToPlayerList(ctx context.Context, files storage.Volume, ep *game.Endpoint, list string, entry game.ListEntry) error {
+	switch list {
+	case "allowlist":
+		if !gamertag.MatchString(entry.Name) {
+			return fmt.Errorf("invalid gamertag %q", entry.Name)
+		}
+		if entry.ID != "" && !xuid.MatchString(entry.ID) {
+			return fmt.Errorf("invalid XUID %q", entry.ID)
+		}
+		var players []allowed
+		if err := readJSON(ctx, files, allowlistFile, &players); err != nil {
+			return err
+		}
+		players = removeAllowed(players, entry.Name)
+		players = append(players, allowed{Name: entry.Name, XUID: entry.ID})
+		if err := writeJSON(ctx, files, allowlistFile, players); err != nil {
+			return err
+		}
+		return reload(ctx, ep, "allowlist reload")
+	case "permissions":
+		if !xuid.MatchString(entry.ID) {
+			return fmt.Errorf("invalid XUID %q", entry.ID)
+		}
+		level := entry.Level
+		if level == "" {
+			level = "operator"
+		}
+		switch level {
+		case "visitor", "member", "operator":
+		default:
+			return fmt.Errorf("invalid permission %q: use visitor, member or operator", level)
+		}
+		var perms []permission
+		if err := readJSON(ctx, files, permissionsFile, &perms); err != nil {
+			return err
+		}
+		perms = removePermission(perms, entry.ID)
+		perms = append(perms, permission{Permission: level, XUID: entry.ID})
+		if err := writeJSON(ctx, files, permissionsFile, perms); err != nil {
+			return err
+		}
+		return reload(ctx, ep, "permission reload")
+	}
+	return fmt.Errorf("%w: %s", game.ErrUnknownList, list)
+}
+
+// RemoveFromPlayerList removes allowlist entries by gamertag or XUID and
+// permissions by XUID.
+func (a *Adapter) RemoveFromPlayerList(ctx context.Context, files storage.Volume, ep *game.Endpoint, list, player string) error {
+	switch list {
+	case "allowlist":
+		var players []allowed
+		if err := readJSON(ctx, files, allowlistFile, &players); err != nil {
+			return err
+		}
+		if err := writeJSON(ctx, files, allowlistFile, removeAllowed(players, player)); err != nil {
+			return err
+		}
+		return reload(ctx, ep, "allowlist reload")
+	case "permissions":
+		var perms []permission
+		if err := readJSON(ctx, files, permissionsFile, &perms); err != nil {
+			return err
+		}
+		if err := writeJSON(ctx, files, permissionsFile, removePermission(perms, player)); err != nil {
+			return err
+		}
+		return reload(ctx, ep, "permission reload")
+	}
+	return fmt.Errorf("%w: %s", game.ErrUnknownList, list)
+}
+
+func removeAllowed(players []allowed, player string) []allowed {
+	kept := []allowed{}
+	for _, p := range players {
+		if !strings.EqualFold(p.Name, player) && (p.XUID == "" || p.XUID != player) {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+func removePermission(perms []permission, id string) []permission {
+	kept := []permission{}
+	for _, p := range perms {
+		if p.XUID != id {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// reload makes a running server read a changed list file.
+func reload(ctx context.Context, ep *game.Endpoint, command string) error {
+	if ep == nil || ep.Console == nil {
+		return nil
+	}
+	if err := ep.Console(ctx, command); err != nil {
+		return fmt.Errorf("saved, but the server didn't reload it: %w", err)
+	}
+	return nil
+}
+
+// readJSON decodes a list file. A missing or empty file is an empty list.
+func readJSON(ctx context.Context, files storage.Volume, name string, v any) error {
+	data, err := files.ReadFile(ctx, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(data)) == "" {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func writeJSON(ctx context.Context, files storage.Volume, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return files.WriteFile(ctx, name, append(data, '\n'))
+}
@@ -0,0 +1,86 @@
+package bedrock
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reedfamily/reedout/internal/game"
+)
+
+const pingTimeout = 5 * time.Second
+
+// RakNet packet IDs and the magic bytes offline messages carry.
+const (
+	unconnectedPing = 0x01
+	unconnectedPong = 0x1c
+)
+
+var offlineMagic = []byte{0x00, 0xff, 0xff, 0x00, 0xfe, 0xfe, 0xfe, 0xfe, 0xfd, 0xfd, 0xfd, 0xfd, 0x12, 0x34, 0x56, 0x78}
+
+// Ping sends a RakNet unconnected ping to addr (host:port) over UDP. The
+// server answers with its MOTD, version and player counts in one string:
+//
+//	MCPE;Dedicated Server;622;1.20.40;0;10;13253860892328930865;Bedrock level;Survival;...
+func Ping(ctx context.Context, addr string) (*game.Status, error) {
+	deadline := time.Now().Add(pingTimeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	sent := time.Now()
+	var req bytes.Buffer
+	req.WriteByte(unconnectedPing)
+	binary.Write(&req, binary.BigEndian, sent.UnixMilli())
+	req.Write(offlineMagic)
+	binary.Write(&req, binary.BigEndian, uint64(0)) // client GUID
+	if _, err := conn.Write(req.Bytes()); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1500)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+	latency := time.Since(sent)
+	return parsePong(buf[:n], latency)
+}
+
+// parsePong reads an unconnected pong: ID, ping time, server GUID, magic and
+// the length-prefixed server ID string.
+func parsePong(b []byte, latency time.Duration) (*game.Status, error) {
+	const header = 1 + 8 + 8 + 16 + 2
+	if len(b) < header || b[0] != unconnectedPong || !bytes.Equal(b[17:33], offlineMagic) {
+		return nil, errors.New("bedrock: unexpected ping response")
+	}
+	size := int(binary.BigEndian.Uint16(b[33:35]))
+	if len(b) < header+size {
+		return nil, errors.New("bedrock: truncated ping response")
+	}
+	fields := strings.Split(string(b[header:header+size]), ";")
+	if len(fields) < 6 {
+		return nil, errors.New("bedrock: malformed server ID")
+	}
+	online, _ := strconv.Atoi(fields[4])
+	max, _ := strconv.Atoi(fields[5])
+	return &game.Status{
+		Version: fields[3],
+		MOTD:    fields[1],
+		Online:  online,
+		Max:     max,
+		Latency: latency.Milliseconds(),
+	}, nil
+}
@@ -0,0 +1,51 @@
+package game
+
+// Capabilities lists which optional features a game's adapter supports, so
+// clients can enable them without knowing about particular games.
+type Capabilities struct {
+	Game        string       `json:"game"`
+	Adapter     bool         `json:"adapter"`      // a registered adapter; false means none of the below
+	Players     bool         `json:"players"`      // player sessions tracked from the logs
+	Commands    bool         `json:"commands"`     // console commands return their output (Commander)
+	Probe       bool         `json:"probe"`        // health checks use a game query (Prober)
+	Status      bool         `json:"status"`       // game status such as player counts (StatusQuerier or EndpointStatusQuerier)
+	Moderation  bool         `json:"moderation"`   // kick, ban and unban (PlayerManager)
+	Save        bool         `json:"save"`         // save the world on demand (SaveController or SaveCommander)
+	Broadcast   bool         `json:"broadcast"`    // message every player (Broadcaster)
+	Worlds      bool         `json:"worlds"`       // list and choose worlds (WorldManager)
+	CreateWorld bool         `json:"create_world"` // create new worlds (WorldCreator)
+	PlayerLists []string     `json:"player_lists"` // names of managed player lists (PlayerLists)
+	ConfigFiles []ConfigFile `json:"config_files"` // known config files (ConfigSchema)
+}
+
+// CapabilitiesOf reports the capabilities of a game's adapter.
+func CapabilitiesOf(gameID string) Capabilities {
+	c := Capabilities{Game: gameID, PlayerLists: []string{}, ConfigFiles: []ConfigFile{}}
+	a := Get(gameID)
+	if a == nil {
+		return c
+	}
+	c.Adapter = true
+	c.Players = true
+	_, c.Commands = a.(Commander)
+	_, c.Probe = a.(Prober)
+	_, c.Status = a.(StatusQuerier)
+	if _, ok := a.(EndpointStatusQuerier); ok {
+		c.Status = true
+	}
+	_, c.Moderation = a.(PlayerManager)
+	_, c.Save = a.(SaveController)
+	if s, ok := a.(SaveCommander); ok && s.SaveCommand() != "" {
+		c.Save = true
+	}
+	_, c.Broadcast = a.(Broadcaster)
+	_, c.Worlds = a.(WorldManager)
+	_, c.CreateWorld = a.(WorldCreator)
+	if l, ok := a.(PlayerLists); ok {
+		c.PlayerLists = append(c.PlayerLists, l.PlayerLists()...)
+	}
+	if s, ok := a.(ConfigSchema); ok {
+		c.ConfigFiles = append(c.ConfigFiles, s.ConfigFiles()...)
+	}
+	return c
+}
@@ -0,0 +1,142 @@
+// Package factorio is the adapter for Factorio servers run from the
+// factoriotools/factorio image.
+package factorio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/game/rcon"
+)
+
+func init() {
+	// Factorio sends each command's output in one packet and doesn't answer
+	// the empty packet used to find the end of split output
+	game.Register(&Adapter{rcons: rcon.Pool{SinglePacket: true}})
+}
+
+type Adapter struct {
+	rcons rcon.Pool
+}
+
+var (
+	// 2024-01-15 10:00:00 [JOIN] Bob joined the game
+	joinRe  = regexp.MustCompile(`\[JOIN\] (\S+) joined the game`)
+	leaveRe = regexp.MustCompile(`\[LEAVE\] (\S+) left the game`)
+	chatRe  = regexp.MustCompile(`\[CHAT\] ([^:<]+): (.*)`)
+	errorRe = regexp.MustCompile(`^\s*\d+\.\d+ Error `)
+
+	playerName = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,60}$`)
+)
+
+func (a *Adapter) Game() string { return "factorio" }
+
+func (a *Adapter) ParseLogLine(line string) *game.LogEvent {
+	if m := joinRe.FindStringSubmatch(line); m != nil {
+		return &game.LogEvent{Type: "player_join", Player: m[1]}
+	}
+	if m := leaveRe.FindStringSubmatch(line); m != nil {
+		return &game.LogEvent{Type: "player_leave", Player: m[1]}
+	}
+	if m := chatRe.FindStringSubmatch(line); m != nil {
+		return &game.LogEvent{Type: "chat", Player: m[1], Message: m[2]}
+	}
+	if errorRe.MatchString(line) {
+		return &game.LogEvent{Type: "error", Message: line}
+	}
+	return nil
+}
+
+func (a *Adapter) PlayerCommand() string { return "/players online" }
+func (a *Adapter) StopCommand() string   { return "/quit" }
+
+// Command runs a command over RCON. The image generates the RCON password
+// into config/rconpw unless RCON_PASSWORD is set.
+func (a *Adapter) Command(ctx context.Context, ep game.Endpoint, command string) (string, error) {
+	port := ep.Ports["rcon"]
+	if port == "" {
+		port = ep.Env["RCON_PORT"]
+	}
+	if port == "" {
+		port = "27015"
+	}
+	password := ep.Env["RCON_PASSWORD"]
+	if password == "" {
+		if ep.Files == nil {
+			return "", errors.New("rcon password unknown: server data is not available")
+		}
+		data, err := ep.Files.ReadFile(ctx, "config/rconpw")
+		if err != nil {
+			return "", fmt.Errorf("read rcon password: %w", err)
+		}
+		password = strings.TrimSpace(string(data))
+	}
+	return a.rcons.Client(net.JoinHostPort(ep.IP, port), password).Command(ctx, command)
+}
+
+// run sends a server command and turns failure messages into errors.
+func (a *Adapter) run(ctx context.Context, ep game.Endpoint, command string) error {
+	out, err := a.Command(ctx, ep, command)
+	if err != nil {
+		return err
+	}
+	out = strings.TrimSpace(out)
+	if strings.Contains(out, "doesn't exist") || strings.HasPrefix(out, "Unknown command") {
+		return errors.New(out)
+	}
+	return nil
+}
+
+func withReason(command, reason string) string {
+	if reason = strings.TrimSpace(reason); reason != "" {
+		return command + " " + reason
+	}
+	return command
+}
+
+func checkPlayer(player string) error {
+	if !playerName.MatchString(player) {
+		return fmt.Errorf("invalid player name %q", player)
+	}
+	return nil
+}
+
+func (a *Adapter) Kick(ctx context.Context, ep game.Endpoint, player, reason string) error {
+	if err := checkPlayer(player); err != nil {
+		return err
+	}
+	return a.run(ctx, ep, withReason("/kick "+player, reason))
+}
+
+func (a *Adapter) Ban(ctx context.Context, ep game.Endpoint, player, reason string) error {
+	if err := checkPlayer(player); err != nil {
+		return err
+	}
+	return a.run(ctx, ep, withReason("/ban "+player, reason))
+}
+
+func (a *Adapter) Unban(ctx context.Context, ep game.Endpoint, player string) error {
+	if err := checkPlayer(player); err != nil {
+		return err
+	}
+	return a.run(ctx, ep, "/unban "+player)
+}
+
+// Save writes the map to the save the server was started with.
+func (a *Adapter) Save(ctx context.Context, ep game.Endpoint) error {
+	return a.run(ctx, ep, "/server-save")
+}
+
+func (a *Adapter) ConfigFiles() []game.ConfigFile {
+	return []game.ConfigFile{
+		{Path: "config/server-settings.json", Format: "json", Description: "Server settings"},
+		{Path: "config/map-gen-settings.json", Format: "json", Description: "Map generation settings for new maps"},
+		{Path: "config/map-settings.json", Format: "json", Description: "Pollution, evolution and expansion settings for new maps"},
+		{Path: "config/server-adminlist.json", Format: "json", Description: "Server admins"},
+	}
+}
@@ -0,0 +1,130 @@
+package factorio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"regexp"
+	"strings"
+
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/storage"
+)
+
+// The image loads saves from saves/ and reads these env vars on start:
+// LOAD_LATEST_SAVE loads the newest save, otherwise SAVE_NAME is loaded,
+// and GENERATE_NEW_SAVE creates SAVE_NAME from the map settings in config/
+// if it doesn't exist yet.
+const (
+	savesDir       = "saves"
+	mapGenSettings = "config/map-gen-settings.json"
+
+	envLoadLatest = "LOAD_LATEST_SAVE"
+	envSaveName   = "SAVE_NAME"
+	envGenerate   = "GENERATE_NEW_SAVE"
+)
+
+var saveName = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// Worlds lists the saves in saves/. The active one is SAVE_NAME, or the
+// newest save while LOAD_LATEST_SAVE is on.
+func (a *Adapter) Worlds(ctx context.Context, files storage.Volume, config map[string]string) ([]game.World, error) {
+	entries, err := files.ReadDir(ctx, savesDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []game.World{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	latest := !strings.EqualFold(config[envLoadLatest], "false")
+	worlds := []game.World{}
+	newest := -1
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name, ".zip")
+		if e.IsDir || !ok {
+			continue
+		}
+		// ModTime is RFC 3339 in UTC, so it sorts as a string
+		if newest < 0 || e.ModTime > worlds[newest].ModTime {
+			newest = len(worlds)
+		}
+		worlds = append(worlds, game.World{
+			Name:    name,
+			Size:    e.Size,
+			ModTime: e.ModTime,
+			Active:  !latest && name == config[envSaveName],
+		})
+	}
+	if latest && newest >= 0 {
+		worlds[newest].Active = true
+	}
+	return worlds, nil
+}
+
+// SelectWorld loads an existing save on the next start.
+func (a *Adapter) SelectWorld(ctx context.Context, files storage.Volume, name string) (map[string]string, error) {
+	if !saveName.MatchString(name) {
+		return nil, fmt.Errorf("invalid save name %q", name)
+	}
+	if !a.exists(ctx, files, name) {
+		return nil, fmt.Errorf("%w: %s", game.ErrUnknownWorld, name)
+	}
+	return map[string]string{envSaveName: name, envLoadLatest: "false", envGenerate: "false"}, nil
+}
+
+// CreateWorld generates a new save on the next start. Settings are keys of
+// map-gen-settings.json, such as seed, width, height and autoplace_controls;
+// they replace the same keys of the current file.
+func (a *Adapter) CreateWorld(ctx context.Context, files storage.Volume, name string, settings json.RawMessage) (map[string]string, error) {
+	if !saveName.MatchString(name) {
+		return nil, fmt.Errorf("invalid save name %q", name)
+	}
+	if a.exists(ctx, files, name) {
+		return nil, fmt.Errorf("%w: %s", game.ErrWorldExists, name)
+	}
+
+	var changes map[string]json.RawMessage
+	if len(settings) > 0 && string(settings) != "null" {
+		if err := json.Unmarshal(settings, &changes); err != nil {
+			return nil, errors.New("settings must be a JSON object of map-gen-settings.json keys")
+		}
+	}
+	if len(changes) > 0 {
+		current := map[string]json.RawMessage{}
+		data, err := files.ReadFile(ctx, mapGenSettings)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, err
+		default:
+			if err := json.Unmarshal(data, &current); err != nil {
+				return nil, fmt.Errorf("%s: %w", mapGenSettings, err)
+			}
+		}
+		maps.Copy(current, changes)
+		out, err := json.MarshalIndent(current, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		if err := files.WriteFile(ctx, mapGenSettings, out); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]string{envSaveName: name, envLoadLatest: "false", envGenerate: "true"}, nil
+}
+
+func (a *Adapter) exists(ctx context.Context, files storage.Volume, name string) bool {
+	entries, err := files.ReadDir(ctx, savesDir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir && e.Name == name+".zip" {
+			return true
+		}
+	}
+	return false
+}
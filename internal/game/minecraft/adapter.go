@@ -1,17 +1,23 @@
 package minecraft
 
 import (
+	"context"
+	"errors"
+	"net"
 	"regexp"
 	"strings"
 
 	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/game/rcon"
 )
 
 func init() {
 	game.Register(&Adapter{})
 }
 
-type Adapter struct{}
+type Adapter struct {
+	rcons rcon.Pool
+}
 
 var (
 	joinRe  = regexp.MustCompile(`\[Server thread/INFO\].*: (\w+) joined the game`)
@@ -39,3 +45,19 @@ func (a *Adapter) ParseLogLine(line string) *game.LogEvent {
 
 func (a *Adapter) PlayerCommand() string { return "list" }
 func (a *Adapter) StopCommand() string   { return "stop" }
+
+// Command runs a command over RCON. Clients are kept per server so the
+// connection is reused between commands.
+func (a *Adapter) Command(ctx context.Context, ep game.Endpoint, command string) (string, error) {
+	if strings.EqualFold(ep.Env["ENABLE_RCON"], "false") {
+		return "", errors.New("rcon is disabled on this server")
+	}
+	port := ep.Ports["rcon"]
+	if port == "" {
+		port = ep.Env["RCON_PORT"]
+	}
+	if port == "" {
+		port = "25575"
+	}
+	return a.rcons.Client(net.JoinHostPort(ep.IP, port), ep.Env["RCON_PASSWORD"]).Command(ctx, command)
+}
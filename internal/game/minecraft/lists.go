@@ -0,0 +1,374 @@
+package minecraft
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/storage"
+)
+
+// A running server keeps its lists in memory and rewrites the files when
+// they change, so changes go through RCON while it runs and into the files
+// while it is stopped.
+const (
+	whitelistFile     = "whitelist.json"
+	opsFile           = "ops.json"
+	bannedPlayersFile = "banned-players.json"
+	bannedIPsFile     = "banned-ips.json"
+)
+
+var uuidRe = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+type whitelisted struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+type op struct {
+	UUID                string `json:"uuid"`
+	Name                string `json:"name"`
+	Level               int    `json:"level"`
+	BypassesPlayerLimit bool   `json:"bypassesPlayerLimit"`
+}
+
+type bannedPlayer struct {
+	UUID    string `json:"uuid"`
+	Name    string `json:"name"`
+	Created string `json:"created"`
+	Source  string `json:"source"`
+	Expires string `json:"expires"`
+	Reason  string `json:"reason"`
+}
+
+type bannedIP struct {
+	IP      string `json:"ip"`
+	Created string `json:"created"`
+	Source  string `json:"source"`
+	Expires string `json:"expires"`
+	Reason  string `json:"reason"`
+}
+
+func (a *Adapter) PlayerLists() []string {
+	return []string{"whitelist", "ops", "bans"}
+}
+
+// ReadPlayerList reads a list from the server's files. Bans list banned
+// players by name and UUID and banned IP addresses by IP.
+func (a *Adapter) ReadPlayerList(ctx context.Context, files storage.Volume, list string) ([]game.ListEntry, error) {
+	entries := []game.ListEntry{}
+	switch list {
+	case "whitelist":
+		var players []whitelisted
+		if err := readJSON(ctx, files, whitelistFile, &players); err != nil {
+			return nil, err
+		}
+		for _, p := range players {
+			entries = append(entries, game.ListEntry{Name: p.Name, ID: p.UUID})
+		}
+	case "ops":
+		var ops []op
+		if err := readJSON(ctx, files, opsFile, &ops); err != nil {
+			return nil, err
+		}
+		for _, p := range ops {
+			entries = append(entries, game.ListEntry{Name: p.Name, ID: p.UUID, Level: strconv.Itoa(p.Level)})
+		}
+	case "bans":
+		var players []bannedPlayer
+		if err := readJSON(ctx, files, bannedPlayersFile, &players); err != nil {
+			return nil, err
+		}
+		for _, p := range players {
+			entries = append(entries, game.ListEntry{Name: p.Name, ID: p.UUID, Reason: p.Reason})
+		}
+		var ips []bannedIP
+		if err := readJSON(ctx, files, bannedIPsFile, &ips); err != nil {
+			return nil, err
+		}
+		for _, b := range ips {
+			entries = append(entries, game.ListEntry{ID: b.IP, Reason: b.Reason})
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", game.ErrUnknownList, list)
+	}
+	return entries, nil
+}
+
+// Commands that change the lists of a running server.
+var (
+	addCommands    = map[string]string{"whitelist": "whitelist add ", "ops": "op ", "bans": "ban "}
+	removeCommands = map[string]string{"whitelist": "whitelist remove ", "ops": "deop ", "bans": "pardon "}
+)
+
+// AddToPlayerList adds a player by name, or bans an IP address given as the
+// ID. A stopped server needs the player's UUID as the ID unless it runs in
+// offline mode. The op level (1-4, default 4) only applies while stopped; a
+// running server uses its op-permission-level.
+func (a *Adapter) AddToPlayerList(ctx context.Context, files storage.Volume, ep *game.Endpoint, list string, entry game.ListEntry) error {
+	if _, ok := addCommands[list]; !ok {
+		return fmt.Errorf("%w: %s", game.ErrUnknownList, list)
+	}
+	if strings.ContainsAny(entry.Reason, "\r\n") {
+		return errors.New("reason must be a single line")
+	}
+	if list == "bans" && entry.Name == "" && net.ParseIP(entry.ID) != nil {
+		if ep != nil {
+			return a.apply(ctx, *ep, withReason("ban-ip "+entry.ID, entry.Reason))
+		}
+		return banIP(ctx, files, entry)
+	}
+
+	if err := checkPlayer(entry.Name); err != nil {
+		return err
+	}
+	level := 4
+	if list == "ops" && entry.Level != "" {
+		n, err := strconv.Atoi(entry.Level)
+		if err != nil || n < 1 || n > 4 {
+			return fmt.Errorf("invalid op level %q: use 1 to 4", entry.Level)
+		}
+		level = n
+	}
+	if ep != nil {
+		command := addCommands[list] + entry.Name
+		if list == "bans" {
+			command = withReason(command, entry.Reason)
+		}
+		return a.apply(ctx, *ep, command)
+	}
+
+	id, err := playerUUID(ctx, files, entry)
+	if err != nil {
+		return err
+	}
+	same := func(name, uuid string) bool {
+		return strings.EqualFold(name, entry.Name) || strings.EqualFold(uuid, id)
+	}
+	switch list {
+	case "whitelist":
+		var players []whitelisted
+		if err := readJSON(ctx, files, whitelistFile, &players); err != nil {
+			return err
+		}
+		players = removeMatching(players, func(p whitelisted) bool { return same(p.Name, p.UUID) })
+		return writeJSON(ctx, files, whitelistFile, append(players, whitelisted{UUID: id, Name: entry.Name}))
+	case "ops":
+		var ops []op
+		if err := readJSON(ctx, files, opsFile, &ops); err != nil {
+			return err
+		}
+		ops = removeMatching(ops, func(p op) bool { return same(p.Name, p.UUID) })
+		return writeJSON(ctx, files, opsFile, append(ops, op{UUID: id, Name: entry.Name, Level: level}))
+	default:
+		var players []bannedPlayer
+		if err := readJSON(ctx, files, bannedPlayersFile, &players); err != nil {
+			return err
+		}
+		players = removeMatching(players, func(p bannedPlayer) bool { return same(p.Name, p.UUID) })
+		return writeJSON(ctx, files, bannedPlayersFile, append(players, bannedPlayer{
+			UUID:    id,
+			Name:    entry.Name,
+			Created: banTime(),
+			Source:  banSource,
+			Expires: "forever",
+			Reason:  banReason(entry.Reason),
+		}))
+	}
+}
+
+// RemoveFromPlayerList removes a player by name or UUID, or an IP ban by IP.
+func (a *Adapter) RemoveFromPlayerList(ctx context.Context, files storage.Volume, ep *game.Endpoint, list, player string) error {
+	if _, ok := removeCommands[list]; !ok {
+		return fmt.Errorf("%w: %s", game.ErrUnknownList, list)
+	}
+	if list == "bans" && net.ParseIP(player) != nil {
+		if ep != nil {
+			return a.apply(ctx, *ep, "pardon-ip "+player)
+		}
+		var ips []bannedIP
+		if err := readJSON(ctx, files, bannedIPsFile, &ips); err != nil {
+			return err
+		}
+		return writeJSON(ctx, files, bannedIPsFile, removeMatching(ips, func(b bannedIP) bool { return b.IP == player }))
+	}
+
+	if ep != nil {
+		// Commands take names, so look up players given by UUID
+		name := player
+		if uuidRe.MatchString(player) {
+			entries, err := a.ReadPlayerList(ctx, files, list)
+			if err != nil {
+				return err
+			}
+			name = ""
+			for _, e := range entries {
+				if strings.EqualFold(e.ID, player) {
+					name = e.Name
+				}
+			}
+			if name == "" {
+				return nil
+			}
+		}
+		if err := checkPlayer(name); err != nil {
+			return err
+		}
+		return a.apply(ctx, *ep, removeCommands[list]+name)
+	}
+
+	switch list {
+	case "whitelist":
+		var players []whitelisted
+		if err := readJSON(ctx, files, whitelistFile, &players); err != nil {
+			return err
+		}
+		return writeJSON(ctx, files, whitelistFile, removeMatching(players, func(p whitelisted) bool { return matches(p.Name, p.UUID, player) }))
+	case "ops":
+		var ops []op
+		if err := readJSON(ctx, files, opsFile, &ops); err != nil {
+			return err
+		}
+		return writeJSON(ctx, files, opsFile, removeMatching(ops, func(p op) bool { return matches(p.Name, p.UUID, player) }))
+	default:
+		var players []bannedPlayer
+		if err := readJSON(ctx, files, bannedPlayersFile, &players); err != nil {
+			return err
+		}
+		return writeJSON(ctx, files, bannedPlayersFile, removeMatching(players, func(p bannedPlayer) bool { return matches(p.Name, p.UUID, player) }))
+	}
+}
+
+// apply runs a list command on a running server. Output saying the list
+// already had the change is not an error.
+func (a *Adapter) apply(ctx context.Context, ep game.Endpoint, command string) error {
+	err := a.run(ctx, ep, command)
+	if err != nil && strings.HasPrefix(err.Error(), "Nothing changed") {
+		return nil
+	}
+	return err
+}
+
+func banIP(ctx context.Context, files storage.Volume, entry game.ListEntry) error {
+	var ips []bannedIP
+	if err := readJSON(ctx, files, bannedIPsFile, &ips); err != nil {
+		return err
+	}
+	ips = removeMatching(ips, func(b bannedIP) bool { return b.IP == entry.ID })
+	return writeJSON(ctx, files, bannedIPsFile, append(ips, bannedIP{
+		IP:      entry.ID,
+		Created: banTime(),
+		Source:  banSource,
+		Expires: "forever",
+		Reason:  banReason(entry.Reason),
+	}))
+}
+
+const banSource = "ReedOut"
+
+// banTime formats the current time the way the server writes ban dates.
+func banTime() string {
+	return time.Now().UTC().Format("2006-01-02 15:04:05 -0700")
+}
+
+func banReason(reason string) string {
+	if reason = strings.TrimSpace(reason); reason != "" {
+		return reason
+	}
+	return "Banned by an operator."
+}
+
+// playerUUID returns the UUID to write for a player: the entry's ID, or the
+// name-based UUID the server itself uses in offline mode. Online-mode UUIDs
+// come from Mojang and can't be worked out from the name.
+func playerUUID(ctx context.Context, files storage.Volume, entry game.ListEntry) (string, error) {
+	if entry.ID != "" {
+		if !uuidRe.MatchString(entry.ID) {
+			return "", fmt.Errorf("invalid UUID %q", entry.ID)
+		}
+		return strings.ToLower(entry.ID), nil
+	}
+	if onlineMode(ctx, files) {
+		return "", errors.New("the server is stopped: give the player's UUID, or start the server to add them by name")
+	}
+	return offlineUUID(entry.Name), nil
+}
+
+// onlineMode reads online-mode from server.properties. It defaults to true,
+// as on the server.
+func onlineMode(ctx context.Context, files storage.Volume) bool {
+	data, err := files.ReadFile(ctx, "server.properties")
+	if err != nil {
+		return true
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok && strings.TrimSpace(key) == "online-mode" {
+			return strings.TrimSpace(value) != "false"
+		}
+	}
+	return true
+}
+
+// offlineUUID is Java's UUID.nameUUIDFromBytes("OfflinePlayer:" + name).
+func offlineUUID(name string) string {
+	sum := md5.Sum([]byte("OfflinePlayer:" + name))
+	sum[6] = sum[6]&0x0f | 0x30
+	sum[8] = sum[8]&0x3f | 0x80
+	h := hex.EncodeToString(sum[:])
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// matches reports whether a list entry is the player given by name or UUID.
+func matches(name, uuid, player string) bool {
+	return strings.EqualFold(name, player) || (uuid != "" && strings.EqualFold(uuid, player))
+}
+
+func removeMatching[T any](entries []T, match func(T) bool) []T {
+	kept := []T{}
+	for _, e := range entries {
+		if !match(e) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// readJSON decodes a list file. A missing or empty file is an empty list.
+func readJSON(ctx context.Context, files storage.Volume, name string, v any) error {
+	data, err := files.ReadFile(ctx, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func writeJSON(ctx context.Context, files storage.Volume, name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return files.WriteFile(ctx, name, append(data, '\n'))
+}
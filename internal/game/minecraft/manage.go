@@ -0,0 +1,87 @@
+package minecraft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/reedfamily/reedout/internal/game"
+)
+
+var playerName = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+
+// failures are the starts of command output that mean nothing happened.
+var failures = []string{
+	"No player was found",
+	"That player does not exist",
+	"Nothing changed",
+	"Unknown or incomplete command",
+	"Incorrect argument for command",
+}
+
+// run sends a command over RCON and turns failure messages into errors.
+func (a *Adapter) run(ctx context.Context, ep game.Endpoint, command string) error {
+	out, err := a.Command(ctx, ep, command)
+	if err != nil {
+		return err
+	}
+	out = strings.TrimSpace(out)
+	for _, f := range failures {
+		if strings.HasPrefix(out, f) {
+			return errors.New(out)
+		}
+	}
+	return nil
+}
+
+func checkPlayer(player string) error {
+	if !playerName.MatchString(player) {
+		return fmt.Errorf("invalid player name %q", player)
+	}
+	return nil
+}
+
+func withReason(command, reason string) string {
+	if reason = strings.TrimSpace(reason); reason != "" {
+		return command + " " + reason
+	}
+	return command
+}
+
+func (a *Adapter) Kick(ctx context.Context, ep game.Endpoint, player, reason string) error {
+	if err := checkPlayer(player); err != nil {
+		return err
+	}
+	return a.run(ctx, ep, withReason("kick "+player, reason))
+}
+
+func (a *Adapter) Ban(ctx context.Context, ep game.Endpoint, player, reason string) error {
+	if err := checkPlayer(player); err != nil {
+		return err
+	}
+	return a.run(ctx, ep, withReason("ban "+player, reason))
+}
+
+func (a *Adapter) Unban(ctx context.Context, ep game.Endpoint, player string) error {
+	if err := checkPlayer(player); err != nil {
+		return err
+	}
+	return a.run(ctx, ep, "pardon "+player)
+}
+
+// Save flushes the world to disk and waits until it is written.
+func (a *Adapter) Save(ctx context.Context, ep game.Endpoint) error {
+	return a.run(ctx, ep, "save-all flush")
+}
+
+func (a *Adapter) ConfigFiles() []game.ConfigFile {
+	return []game.ConfigFile{
+		{Path: "server.properties", Format: "properties", Description: "Server settings"},
+		{Path: "whitelist.json", Format: "json", Description: "Players allowed to join when the whitelist is on"},
+		{Path: "ops.json", Format: "json", Description: "Server operators"},
+		{Path: "banned-players.json", Format: "json", Description: "Banned players"},
+		{Path: "banned-ips.json", Format: "json", Description: "Banned IP addresses"},
+	}
+}
@@ -0,0 +1,242 @@
+package minecraft
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reedfamily/reedout/internal/game"
+)
+
+const (
+	slpTimeout = 5 * time.Second
+	// maxStatus bounds the status JSON; favicons make it a few KB.
+	maxStatus = 1 << 20
+)
+
+// slpResponse is the JSON a server answers a status request with.
+type slpResponse struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int    `json:"protocol"`
+	} `json:"version"`
+	Players struct {
+		Max    int `json:"max"`
+		Online int `json:"online"`
+		Sample []struct {
+			Name string `json:"name"`
+		} `json:"sample"`
+	} `json:"players"`
+	Description json.RawMessage `json:"description"`
+}
+
+// Ping runs a Server List Ping against addr (host:port): a handshake, a
+// status request and a ping to measure latency.
+func Ping(ctx context.Context, addr string) (*game.Status, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %q", portStr)
+	}
+
+	d := net.Dialer{Timeout: slpTimeout}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(slpTimeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	conn.SetDeadline(deadline)
+
+	// Handshake with protocol -1 ("any"), next state 1 (status), then the
+	// status request
+	var hs bytes.Buffer
+	writeVarInt(&hs, 0x00)
+	writeVarInt(&hs, -1)
+	writeString(&hs, host)
+	binary.Write(&hs, binary.BigEndian, uint16(port))
+	writeVarInt(&hs, 1)
+	if err := writePacket(conn, hs.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := writePacket(conn, []byte{0x00}); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	body, err := readPacket(r, 0x00)
+	if err != nil {
+		return nil, fmt.Errorf("status: %w", err)
+	}
+	data, err := readString(bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("status: %w", err)
+	}
+	var resp slpResponse
+	if err := json.Unmarshal([]byte(data), &resp); err != nil {
+		return nil, fmt.Errorf("status: %w", err)
+	}
+
+	status := &game.Status{
+		Version: resp.Version.Name,
+		MOTD:    chatText(resp.Description),
+		Online:  resp.Players.Online,
+		Max:     resp.Players.Max,
+	}
+	for _, p := range resp.Players.Sample {
+		status.Players = append(status.Players, p.Name)
+	}
+
+	// Latency is the ping round trip; old servers close instead of answering
+	var ping bytes.Buffer
+	writeVarInt(&ping, 0x01)
+	sent := time.Now()
+	binary.Write(&ping, binary.BigEndian, sent.UnixMilli())
+	if err := writePacket(conn, ping.Bytes()); err == nil {
+		if _, err := readPacket(r, 0x01); err == nil {
+			status.Latency = time.Since(sent).Milliseconds()
+		}
+	}
+	return status, nil
+}
+
+// Probe reports whether the server answers status requests.
+func (a *Adapter) Probe(ctx context.Context, addr string) error {
+	_, err := Ping(ctx, addr)
+	return err
+}
+
+// Status queries the server list status.
+func (a *Adapter) Status(ctx context.Context, addr string) (*game.Status, error) {
+	return Ping(ctx, addr)
+}
+
+func writePacket(w io.Writer, data []byte) error {
+	var buf bytes.Buffer
+	writeVarInt(&buf, int32(len(data)))
+	buf.Write(data)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// readPacket reads one packet and checks its ID, returning the rest.
+func readPacket(r *bufio.Reader, id int32) ([]byte, error) {
+	size, err := readVarInt(r)
+	if err != nil {
+		return nil, err
+	}
+	if size < 1 || size > maxStatus {
+		return nil, fmt.Errorf("invalid packet size %d", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	br := bytes.NewReader(data)
+	got, err := readVarInt(br)
+	if err != nil {
+		return nil, err
+	}
+	if got != id {
+		return nil, fmt.Errorf("unexpected packet 0x%02x", got)
+	}
+	return data[len(data)-br.Len():], nil
+}
+
+func writeVarInt(buf *bytes.Buffer, v int32) {
+	u := uint32(v)
+	for {
+		if u&^0x7f == 0 {
+			buf.WriteByte(byte(u))
+			return
+		}
+		buf.WriteByte(byte(u&0x7f | 0x80))
+		u >>= 7
+	}
+}
+
+func readVarInt(r io.ByteReader) (int32, error) {
+	var v uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint32(b&0x7f) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(v), nil
+		}
+	}
+	return 0, errors.New("varint too long")
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	writeVarInt(buf, int32(len(s)))
+	buf.WriteString(s)
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	n, err := readVarInt(r)
+	if err != nil {
+		return "", err
+	}
+	if n < 0 || int(n) > r.Len() {
+		return "", fmt.Errorf("invalid string length %d", n)
+	}
+	b := make([]byte, n)
+	io.ReadFull(r, b)
+	return string(b), nil
+}
+
+var formatCodes = regexp.MustCompile(`§.`)
+
+// chatText flattens a chat component (a string or {"text", "extra"}) into
+// plain text without formatting codes.
+func chatText(raw json.RawMessage) string {
+	var b strings.Builder
+	var walk func(raw json.RawMessage)
+	walk = func(raw json.RawMessage) {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			b.WriteString(s)
+			return
+		}
+		var list []json.RawMessage
+		if json.Unmarshal(raw, &list) == nil {
+			for _, c := range list {
+				walk(c)
+			}
+			return
+		}
+		var c struct {
+			Text  string            `json:"text"`
+			Extra []json.RawMessage `json:"extra"`
+		}
+		if json.Unmarshal(raw, &c) == nil {
+			b.WriteString(c.Text)
+			for _, e := range c.Extra {
+				walk(e)
+			}
+		}
+	}
+	if len(raw) > 0 {
+		walk(raw)
+	}
+	return strings.TrimSpace(formatCodes.ReplaceAllString(b.String(), ""))
+}
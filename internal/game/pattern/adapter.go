@@ -0,0 +1,85 @@
+// Package pattern is a game adapter driven by the log patterns and console
+// commands a template declares, so games can be added without Go code.
+package pattern
+
+import (
+	"regexp"
+
+	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/game"
+)
+
+type Adapter struct {
+	game  string
+	spec  docker.AdapterSpec
+	join  *regexp.Regexp
+	leave *regexp.Regexp
+	chat  *regexp.Regexp
+	error *regexp.Regexp
+}
+
+// New compiles a template's adapter declaration for a game.
+func New(gameID string, spec docker.AdapterSpec) (*Adapter, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	a := &Adapter{game: gameID, spec: spec}
+	for _, p := range []struct {
+		re      **regexp.Regexp
+		pattern string
+	}{
+		{&a.join, spec.Patterns.Join},
+		{&a.leave, spec.Patterns.Leave},
+		{&a.chat, spec.Patterns.Chat},
+		{&a.error, spec.Patterns.Error},
+	} {
+		if p.pattern != "" {
+			*p.re = regexp.MustCompile(p.pattern)
+		}
+	}
+	return a, nil
+}
+
+func (a *Adapter) Game() string { return a.game }
+
+func (a *Adapter) ParseLogLine(line string) *game.LogEvent {
+	if m := match(a.join, line); m != nil {
+		return &game.LogEvent{Type: "player_join", Player: m["player"]}
+	}
+	if m := match(a.leave, line); m != nil {
+		return &game.LogEvent{Type: "player_leave", Player: m["player"]}
+	}
+	if m := match(a.chat, line); m != nil {
+		return &game.LogEvent{Type: "chat", Player: m["player"], Message: m["message"]}
+	}
+	if m := match(a.error, line); m != nil {
+		msg, ok := m["message"]
+		if !ok {
+			msg = line
+		}
+		return &game.LogEvent{Type: "error", Message: msg}
+	}
+	return nil
+}
+
+func (a *Adapter) PlayerCommand() string { return a.spec.Commands.List }
+func (a *Adapter) StopCommand() string   { return a.spec.Commands.Stop }
+func (a *Adapter) SaveCommand() string   { return a.spec.Commands.Save }
+
+// match returns the named groups of the first match, or nil.
+func match(re *regexp.Regexp, line string) map[string]string {
+	if re == nil {
+		return nil
+	}
+	m := re.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	groups := make(map[string]string, len(m))
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			groups[name] = m[i]
+		}
+	}
+	return groups
+}
@@ -0,0 +1,239 @@
+// Package rcon is a client for the Source RCON protocol, which Source games,
+// Minecraft and Factorio among others use for remote console commands.
+package rcon
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Source RCON packet types. Auth responses and command requests share a value.
+const (
+	packetResponse     int32 = 0
+	packetCommand      int32 = 2
+	packetAuthResponse int32 = 2
+	packetAuth         int32 = 3
+)
+
+const (
+	timeout = 10 * time.Second
+	// maxPacket bounds a single packet. Most servers split longer output;
+	// some, like Factorio, send it in one large packet.
+	maxPacket = 4 << 20
+)
+
+// ErrAuth is returned when the server rejects the RCON password.
+var ErrAuth = errors.New("rcon: authentication failed")
+
+// Client is a Source RCON client. It connects on first use, reconnects when
+// the connection drops and is safe for concurrent use; commands are sent one
+// at a time.
+type Client struct {
+	addr     string
+	password string
+
+	// SinglePacket makes a command's output a single response packet,
+	// for servers that don't answer the empty packet used to find the end
+	// of split output.
+	SinglePacket bool
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int32
+}
+
+func New(addr, password string) *Client {
+	return &Client{addr: addr, password: password}
+}
+
+// Command runs a command and returns its output. Output split over several
+// packets is joined. When a reused connection turns out to be closed before
+// the server answered, the command is retried once on a new connection.
+func (c *Client) Command(ctx context.Context, command string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reused := c.conn != nil
+	out, sent, err := c.command(ctx, command)
+	if err != nil && reused && !sent && ctx.Err() == nil {
+		c.close()
+		out, _, err = c.command(ctx, command)
+	}
+	if err != nil {
+		c.close()
+	}
+	return out, err
+}
+
+// Close closes the connection. The client reconnects on the next command.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.close()
+}
+
+func (c *Client) close() error {
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn, c.reader = nil, nil
+	return err
+}
+
+func (c *Client) command(ctx context.Context, command string) (out string, sent bool, err error) {
+	if c.conn == nil {
+		if err := c.connect(ctx); err != nil {
+			return "", false, err
+		}
+	}
+	c.setDeadline(ctx)
+
+	// The server answers packets in order, so an empty response packet sent
+	// after the command marks the end of the command's output.
+	id := c.id()
+	end := c.id()
+	if err := c.write(id, packetCommand, command); err != nil {
+		return "", false, err
+	}
+	if c.SinglePacket {
+		end = id
+	} else if err := c.write(end, packetResponse, ""); err != nil {
+		return "", false, err
+	}
+
+	var b strings.Builder
+	answered := false
+	for {
+		pid, _, body, err := c.read()
+		if err != nil {
+			// A connection the server already closed fails before any reply
+			closed := errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+			return "", answered || !closed, err
+		}
+		answered = true
+		if pid == id {
+			b.WriteString(body)
+		}
+		if pid == end {
+			return b.String(), true, nil
+		}
+	}
+}
+
+func (c *Client) connect(ctx context.Context) error {
+	d := net.Dialer{Timeout: timeout}
+	conn, err := d.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return fmt.Errorf("rcon: %w", err)
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+	c.setDeadline(ctx)
+
+	id := c.id()
+	if err := c.write(id, packetAuth, c.password); err != nil {
+		return err
+	}
+	// Source servers send an empty response before the auth result
+	for {
+		pid, typ, _, err := c.read()
+		if err != nil {
+			return err
+		}
+		if typ != packetAuthResponse {
+			continue
+		}
+		if pid == -1 {
+			return ErrAuth
+		}
+		if pid == id {
+			return nil
+		}
+	}
+}
+
+func (c *Client) setDeadline(ctx context.Context) {
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	c.conn.SetDeadline(deadline)
+}
+
+func (c *Client) id() int32 {
+	c.nextID++
+	if c.nextID <= 0 {
+		c.nextID = 1
+	}
+	return c.nextID
+}
+
+// Packets are: int32 length, int32 id, int32 type, body, two NUL bytes.
+// All integers are little-endian; length counts everything after itself.
+func (c *Client) write(id, typ int32, body string) error {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(10+len(body)))
+	binary.Write(&buf, binary.LittleEndian, id)
+	binary.Write(&buf, binary.LittleEndian, typ)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+	if _, err := c.conn.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("rcon: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) read() (id, typ int32, body string, err error) {
+	var size int32
+	if err := binary.Read(c.reader, binary.LittleEndian, &size); err != nil {
+		return 0, 0, "", fmt.Errorf("rcon: %w", err)
+	}
+	if size < 10 || size > maxPacket {
+		return 0, 0, "", fmt.Errorf("rcon: invalid packet size %d", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(c.reader, data); err != nil {
+		return 0, 0, "", fmt.Errorf("rcon: %w", err)
+	}
+	id = int32(binary.LittleEndian.Uint32(data[0:4]))
+	typ = int32(binary.LittleEndian.Uint32(data[4:8]))
+	return id, typ, string(bytes.TrimRight(data[8:], "\x00")), nil
+}
+
+// Pool keeps one client per server address and password, so connections
+// are reused between commands.
+type Pool struct {
+	// SinglePacket is set on the pool's clients.
+	SinglePacket bool
+
+	mu      sync.Mutex
+	clients map[string]*Client
+}
+
+// Client returns the pool's client for addr and password.
+func (p *Pool) Client(addr, password string) *Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.clients == nil {
+		p.clients = make(map[string]*Client)
+	}
+	key := addr + "\x00" + password
+	c := p.clients[key]
+	if c == nil {
+		c = New(addr, password)
+		c.SinglePacket = p.SinglePacket
+		p.clients[key] = c
+	}
+	return c
+}
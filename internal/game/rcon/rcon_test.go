@@ -0,0 +1,244 @@
+package rcon
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeServer is a Source RCON server that answers each command with the
+// chunks reply returns, one packet per chunk.
+type fakeServer struct {
+	password string
+	reply    func(command string) []string
+
+	// closeAfter closes each connection once it has answered that many
+	// commands, as servers do with idle connections.
+	closeAfter int
+	// dropMidway closes the connection after the first chunk of output.
+	dropMidway bool
+	// noEndMarker ignores empty response packets, like Factorio.
+	noEndMarker bool
+
+	conns    atomic.Int32
+	commands atomic.Int32
+}
+
+func startServer(t *testing.T, s *fakeServer) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.conns.Add(1)
+			go s.serve(conn)
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	answered := 0
+	for {
+		id, typ, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		switch {
+		case typ == packetAuth:
+			writePacket(conn, id, packetResponse, "")
+			if body != s.password {
+				id = -1
+			}
+			writePacket(conn, id, packetAuthResponse, "")
+		case typ == packetCommand:
+			s.commands.Add(1)
+			for i, chunk := range s.reply(body) {
+				writePacket(conn, id, packetResponse, chunk)
+				if s.dropMidway && i == 0 {
+					return
+				}
+			}
+			answered++
+			if s.noEndMarker && answered == s.closeAfter {
+				return
+			}
+		case typ == packetResponse && !s.noEndMarker:
+			writePacket(conn, id, packetResponse, "")
+			if answered == s.closeAfter {
+				return
+			}
+		}
+	}
+}
+
+func readPacket(r io.Reader) (id, typ int32, body string, err error) {
+	var size int32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return 0, 0, "", err
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, 0, "", err
+	}
+	id = int32(binary.LittleEndian.Uint32(data[0:4]))
+	typ = int32(binary.LittleEndian.Uint32(data[4:8]))
+	return id, typ, string(bytes.TrimRight(data[8:], "\x00")), nil
+}
+
+func writePacket(w io.Writer, id, typ int32, body string) {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, int32(10+len(body)))
+	binary.Write(&buf, binary.LittleEndian, id)
+	binary.Write(&buf, binary.LittleEndian, typ)
+	buf.WriteString(body)
+	buf.Write([]byte{0, 0})
+	w.Write(buf.Bytes())
+}
+
+// errAny matches any error, for failures whose cause depends on timing.
+var errAny = errors.New("any error")
+
+func echo(command string) []string {
+	return []string{"ran " + command}
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name         string
+		server       *fakeServer
+		password     string
+		singlePacket bool
+		commands     []string
+		want         []string
+		wantErr      error
+		wantConns    int32
+		wantCommands int32
+	}{
+		{
+			name:         "reuses the connection",
+			server:       &fakeServer{reply: echo},
+			commands:     []string{"list", "save"},
+			want:         []string{"ran list", "ran save"},
+			wantConns:    1,
+			wantCommands: 2,
+		},
+		{
+			name: "joins split output",
+			server: &fakeServer{reply: func(string) []string {
+				return []string{"first ", "second ", "third"}
+			}},
+			commands:     []string{"status"},
+			want:         []string{"first second third"},
+			wantConns:    1,
+			wantCommands: 1,
+		},
+		{
+			name:         "single packet output",
+			server:       &fakeServer{reply: echo, noEndMarker: true},
+			singlePacket: true,
+			commands:     []string{"/players", "/time"},
+			want:         []string{"ran /players", "ran /time"},
+			wantConns:    1,
+			wantCommands: 2,
+		},
+		{
+			name:         "reconnects after the server closes the connection",
+			server:       &fakeServer{reply: echo, closeAfter: 1},
+			commands:     []string{"list", "save", "stop"},
+			want:         []string{"ran list", "ran save", "ran stop"},
+			wantConns:    3,
+			wantCommands: 3,
+		},
+		{
+			name:         "reconnects in single packet mode",
+			server:       &fakeServer{reply: echo, closeAfter: 1, noEndMarker: true},
+			singlePacket: true,
+			commands:     []string{"/players", "/time"},
+			want:         []string{"ran /players", "ran /time"},
+			wantConns:    2,
+			wantCommands: 2,
+		},
+		{
+			name: "does not retry a command the server started answering",
+			server: &fakeServer{dropMidway: true, reply: func(string) []string {
+				return []string{"partial", "rest"}
+			}},
+			commands:     []string{"save"},
+			wantErr:      errAny,
+			wantConns:    1,
+			wantCommands: 1,
+		},
+		{
+			name:         "rejected password",
+			server:       &fakeServer{reply: echo},
+			password:     "wrong",
+			commands:     []string{"list"},
+			wantErr:      ErrAuth,
+			wantConns:    1,
+			wantCommands: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.server.password = "secret"
+			password := tt.password
+			if password == "" {
+				password = tt.server.password
+			}
+			c := New(startServer(t, tt.server), password)
+			c.SinglePacket = tt.singlePacket
+			defer c.Close()
+
+			var got []string
+			var err error
+			for _, cmd := range tt.commands {
+				var out string
+				out, err = c.Command(context.Background(), cmd)
+				if err != nil {
+					break
+				}
+				got = append(got, out)
+			}
+
+			switch {
+			case tt.wantErr == errAny:
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+			if n := tt.server.conns.Load(); n != tt.wantConns {
+				t.Errorf("connections = %d, want %d", n, tt.wantConns)
+			}
+			if n := tt.server.commands.Load(); n != tt.wantCommands {
+				t.Errorf("commands received = %d, want %d", n, tt.wantCommands)
+			}
+		})
+	}
+}
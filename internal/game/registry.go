@@ -5,6 +5,7 @@ import "sync"
 var (
 	mu       sync.RWMutex
 	adapters = map[string]GameAdapter{}
+	declared = map[string]GameAdapter{} // from templates, see SetDeclared
 )
 
 func Register(adapter GameAdapter) {
@@ -13,16 +14,45 @@ func Register(adapter GameAdapter) {
 	adapters[adapter.Game()] = adapter
 }
 
+// SetDeclared replaces the adapters declared by templates. Adapters
+// registered in code take precedence over declared ones.
+func SetDeclared(list []GameAdapter) {
+	m := make(map[string]GameAdapter, len(list))
+	for _, a := range list {
+		m[a.Game()] = a
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	declared = m
+}
+
+// Builtin reports whether a game has an adapter registered in code.
+func Builtin(game string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := adapters[game]
+	return ok
+}
+
 func Get(game string) GameAdapter {
 	mu.RLock()
 	defer mu.RUnlock()
-	return adapters[game]
+	if a, ok := adapters[game]; ok {
+		return a
+	}
+	if a, ok := declared[game]; ok {
+		return a
+	}
+	return nil
 }
 
 func All() map[string]GameAdapter {
 	mu.RLock()
 	defer mu.RUnlock()
-	result := make(map[string]GameAdapter, len(adapters))
+	result := make(map[string]GameAdapter, len(adapters)+len(declared))
+	for k, v := range declared {
+		result[k] = v
+	}
 	for k, v := range adapters {
 		result[k] = v
 	}
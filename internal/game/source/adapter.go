@@ -0,0 +1,110 @@
+// Package source is the adapter for Source engine and other Steam dedicated
+// servers, which answer the A2S queries.
+package source
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/game/a2s"
+	"github.com/reedfamily/reedout/internal/game/rcon"
+)
+
+func init() {
+	game.Register(&Adapter{})
+}
+
+type Adapter struct {
+	rcons rcon.Pool
+}
+
+var (
+	// L 01/02/2024 - 12:00:00: "Name<2><[U:1:123]><>" entered the game
+	joinRe  = regexp.MustCompile(`"(.+?)<\d+><[^>]*><[^>]*>" entered the game`)
+	leaveRe = regexp.MustCompile(`"(.+?)<\d+><[^>]*><[^>]*>" disconnected`)
+	chatRe  = regexp.MustCompile(`"(.+?)<\d+><[^>]*><[^>]*>" say "(.*)"`)
+	// Console output without log files enabled
+	connectRe = regexp.MustCompile(`^Client "(.+)" connected`)
+	droppedRe = regexp.MustCompile(`^Dropped (.+) from server`)
+)
+
+func (a *Adapter) Game() string { return "source" }
+
+func (a *Adapter) ParseLogLine(line string) *game.LogEvent {
+	if m := joinRe.FindStringSubmatch(line); m != nil {
+		return &game.LogEvent{Type: "player_join", Player: m[1]}
+	}
+	if m := leaveRe.FindStringSubmatch(line); m != nil {
+		return &game.LogEvent{Type: "player_leave", Player: m[1]}
+	}
+	if m := chatRe.FindStringSubmatch(line); m != nil {
+		return &game.LogEvent{Type: "chat", Player: m[1], Message: m[2]}
+	}
+	if m := connectRe.FindStringSubmatch(line); m != nil {
+		return &game.LogEvent{Type: "player_join", Player: m[1]}
+	}
+	if m := droppedRe.FindStringSubmatch(line); m != nil {
+		return &game.LogEvent{Type: "player_leave", Player: m[1]}
+	}
+	if strings.Contains(line, "ERROR") || strings.Contains(line, "FATAL") {
+		return &game.LogEvent{Type: "error", Message: line}
+	}
+	return nil
+}
+
+func (a *Adapter) PlayerCommand() string { return "status" }
+func (a *Adapter) StopCommand() string   { return "quit" }
+
+// Command runs a command over RCON, which Source servers serve on the game
+// port over TCP. Servers without an RCON password take commands on stdin.
+func (a *Adapter) Command(ctx context.Context, ep game.Endpoint, command string) (string, error) {
+	password := ep.Env["SRCDS_RCONPW"]
+	if password == "" {
+		return "", game.ErrNotAvailable
+	}
+	port := ep.Ports["rcon"]
+	if port == "" {
+		port = ep.Env["SRCDS_PORT"]
+	}
+	if port == "" {
+		port = "27015"
+	}
+	return a.rcons.Client(net.JoinHostPort(ep.IP, port), password).Command(ctx, command)
+}
+
+// Probe reports whether the server answers A2S_INFO.
+func (a *Adapter) Probe(ctx context.Context, addr string) error {
+	_, err := (&a2s.Client{Addr: addr}).Info(ctx)
+	return err
+}
+
+// Status queries A2S_INFO and A2S_PLAYER. Servers that don't answer player
+// queries still report their counts.
+func (a *Adapter) Status(ctx context.Context, addr string) (*game.Status, error) {
+	client := &a2s.Client{Addr: addr}
+	sent := time.Now()
+	info, err := client.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := &game.Status{
+		Version: info.Version,
+		MOTD:    info.Name,
+		Online:  info.Players,
+		Max:     info.MaxPlayers,
+		Latency: time.Since(sent).Milliseconds(),
+	}
+	if players, err := client.Players(ctx); err == nil {
+		for _, p := range players {
+			// Connecting players are listed without a name
+			if p.Name != "" {
+				status.Players = append(status.Players, p.Name)
+			}
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,238 @@
+// Package terraria is the adapter for Terraria servers run from the
+// ryshe/terraria image. Servers running TShock are managed through its REST
+// API; vanilla servers only through the console.
+package terraria
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/game/tshock"
+)
+
+func init() {
+	game.Register(&Adapter{})
+}
+
+type Adapter struct{}
+
+var (
+	// Console lines may start with the ": " prompt
+	joinRe  = regexp.MustCompile(`^(?:: )?(.+) has joined\.$`)
+	leaveRe = regexp.MustCompile(`^(?:: )?(.+) has left\.$`)
+	chatRe  = regexp.MustCompile(`^(?:: )?<(.+?)> (.*)$`)
+
+	playerName = regexp.MustCompile(`^[^\x00-\x1f]{1,32}$`)
+)
+
+func (a *Adapter) Game() string { return "terraria" }
+
+func (a *Adapter) ParseLogLine(line string) *game.LogEvent {
+	if m := joinRe.FindStringSubmatch(line); m != nil {
+		return &game.LogEvent{Type: "player_join", Player: m[1]}
+	}
+	if m := leaveRe.FindStringSubmatch(line); m != nil {
+		return &game.LogEvent{Type: "player_leave", Player: m[1]}
+	}
+	if m := chatRe.FindStringSubmatch(line); m != nil {
+		return &game.LogEvent{Type: "chat", Player: m[1], Message: m[2]}
+	}
+	if strings.Contains(line, "Exception") || strings.Contains(line, "Error") {
+		return &game.LogEvent{Type: "error", Message: line}
+	}
+	return nil
+}
+
+// TShock accepts the vanilla console commands as aliases.
+func (a *Adapter) PlayerCommand() string { return "playing" }
+func (a *Adapter) StopCommand() string   { return "exit" }
+func (a *Adapter) SaveCommand() string   { return "save" }
+
+// rest returns a client for the server's TShock REST API. The token is
+// TSHOCK_REST_TOKEN, which the template's install step writes into TShock's
+// config, or else the first application token in config.json.
+func (a *Adapter) rest(ctx context.Context, ep game.Endpoint) (*tshock.Client, error) {
+	token := ep.Env["TSHOCK_REST_TOKEN"]
+	if token == "" && ep.Files != nil {
+		token = configToken(ctx, ep)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("TShock REST API: %w", game.ErrNotAvailable)
+	}
+	port := ep.Ports["web"]
+	if port == "" {
+		port = "7878"
+	}
+	return &tshock.Client{BaseURL: "http://" + net.JoinHostPort(ep.IP, port), Token: token}, nil
+}
+
+// configFile is where TShock's settings live in the server data. The image
+// starts TShock with -configpath /config, where the template mounts it.
+const configFile = "config/config.json"
+
+// configToken reads an application token from TShock's config.json, which
+// nests its settings under "Settings" since TShock 5.
+func configToken(ctx context.Context, ep game.Endpoint) string {
+	data, err := ep.Files.ReadFile(ctx, configFile)
+	if err != nil {
+		return ""
+	}
+	type settings struct {
+		RestApiEnabled        bool
+		ApplicationRestTokens map[string]json.RawMessage
+	}
+	var config struct {
+		settings
+		Settings *settings
+	}
+	if json.Unmarshal(data, &config) != nil {
+		return ""
+	}
+	s := config.settings
+	if config.Settings != nil {
+		s = *config.Settings
+	}
+	if !s.RestApiEnabled {
+		return ""
+	}
+	for token := range s.ApplicationRestTokens {
+		return token
+	}
+	return ""
+}
+
+// noREST turns a refused connection to the REST API into
+// game.ErrNotAvailable. Vanilla servers have a token in their environment
+// but nothing listening on the REST port.
+func noREST(err error) error {
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("TShock REST API: %w", game.ErrNotAvailable)
+	}
+	return err
+}
+
+// Command runs a command through the REST API. Vanilla servers return
+// game.ErrNotAvailable so commands go to the console instead.
+func (a *Adapter) Command(ctx context.Context, ep game.Endpoint, command string) (string, error) {
+	client, err := a.rest(ctx, ep)
+	if err != nil {
+		return "", err
+	}
+	out, err := client.Command(ctx, command)
+	return out, noREST(err)
+}
+
+func (a *Adapter) Broadcast(ctx context.Context, ep game.Endpoint, message string) error {
+	client, err := a.rest(ctx, ep)
+	if err != nil {
+		return err
+	}
+	return noREST(client.Broadcast(ctx, message))
+}
+
+// EndpointStatus reports the server's counts from the REST API and its
+// online players from the player list.
+func (a *Adapter) EndpointStatus(ctx context.Context, ep game.Endpoint) (*game.Status, error) {
+	client, err := a.rest(ctx, ep)
+	if err != nil {
+		return nil, err
+	}
+	sent := time.Now()
+	server, err := client.Status(ctx)
+	if err != nil {
+		return nil, noREST(err)
+	}
+	status := &game.Status{
+		Version: server.ServerVersion,
+		MOTD:    server.Name,
+		Online:  server.PlayerCount,
+		Max:     server.MaxPlayers,
+		Latency: time.Since(sent).Milliseconds(),
+	}
+	players, err := client.Players(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range players {
+		status.Players = append(status.Players, p.Nickname)
+	}
+	return status, nil
+}
+
+func checkPlayer(player string) error {
+	if !playerName.MatchString(player) {
+		return fmt.Errorf("invalid player name %q", player)
+	}
+	return nil
+}
+
+func (a *Adapter) Kick(ctx context.Context, ep game.Endpoint, player, reason string) error {
+	if err := checkPlayer(player); err != nil {
+		return err
+	}
+	client, err := a.rest(ctx, ep)
+	if err != nil {
+		return err
+	}
+	return noREST(client.Kick(ctx, player, reason))
+}
+
+// Ban bans a character name and kicks the player if they are online.
+func (a *Adapter) Ban(ctx context.Context, ep game.Endpoint, player, reason string) error {
+	if err := checkPlayer(player); err != nil {
+		return err
+	}
+	client, err := a.rest(ctx, ep)
+	if err != nil {
+		return err
+	}
+	if err := client.Ban(ctx, "name:"+player, reason); err != nil {
+		return noREST(err)
+	}
+	client.Kick(ctx, player, reason)
+	return nil
+}
+
+// Unban removes the bans on a character or account name.
+func (a *Adapter) Unban(ctx context.Context, ep game.Endpoint, player string) error {
+	if err := checkPlayer(player); err != nil {
+		return err
+	}
+	client, err := a.rest(ctx, ep)
+	if err != nil {
+		return err
+	}
+	bans, err := client.Bans(ctx)
+	if err != nil {
+		return noREST(err)
+	}
+	found := false
+	for _, b := range bans {
+		if b.Identifier != "name:"+player && b.Identifier != "acc:"+player {
+			continue
+		}
+		if err := client.Unban(ctx, b.Ticket); err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return errors.New(player + " is not banned")
+	}
+	return nil
+}
+
+func (a *Adapter) ConfigFiles() []game.ConfigFile {
+	return []game.ConfigFile{
+		{Path: configFile, Format: "json", Description: "TShock settings"},
+		{Path: "config/sscconfig.json", Format: "json", Description: "TShock server-side character settings"},
+	}
+}
@@ -0,0 +1,104 @@
+package terraria
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/storage"
+)
+
+// dirFiles serves server files from a directory.
+type dirFiles struct {
+	storage.Volume
+	dir string
+}
+
+func (f dirFiles) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(f.dir, filepath.FromSlash(name)))
+}
+
+// TestTemplateEnablesREST runs the template's install script and checks that
+// TShock finds the REST settings at /config and that the API is called with
+// the token the script registered.
+func TestTemplateEnablesREST(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell to run the install script")
+	}
+	templates, err := docker.LoadTemplates("../../../templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tmpl *docker.GameTemplate
+	for i := range templates {
+		if templates[i].ID == "terraria" {
+			tmpl = &templates[i]
+		}
+	}
+	if tmpl == nil || tmpl.Install == nil {
+		t.Fatal("terraria template with an install script not found")
+	}
+
+	// The image reads its settings from /config; that must be part of the data
+	var configDir string
+	for source, target := range tmpl.Volumes {
+		if target == "/config" {
+			configDir = strings.TrimPrefix(source, "{data_dir}")
+		}
+	}
+	if configDir == "" {
+		t.Fatalf("template does not mount a data subdirectory at /config: %v", tmpl.Volumes)
+	}
+	if want := strings.TrimPrefix(configDir, "/") + "/config.json"; configFile != want {
+		t.Fatalf("adapter reads %s, but TShock writes %s", configFile, want)
+	}
+
+	// Run the install script against a scratch data directory
+	data := t.TempDir()
+	const token = "generated-token-0123456789abcdef"
+	script := strings.ReplaceAll(tmpl.Install.Script, "/mnt/server", data)
+	cmd := exec.Command(sh, "-c", script)
+	cmd.Env = append(os.Environ(), "TSHOCK_REST_TOKEN="+token)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("install script: %v\n%s", err, out)
+	}
+
+	files := dirFiles{dir: data}
+	if got := configToken(context.Background(), game.Endpoint{Files: files}); got != token {
+		t.Fatalf("token in %s = %q, want %q", configFile, got, token)
+	}
+
+	// The REST API accepts only the registered token
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("token") != token {
+			w.Write([]byte(`{"status":"403","error":"Not authorized"}`))
+			return
+		}
+		w.Write([]byte(`{"status":"200","response":["Saved"]}`))
+	}))
+	defer srv.Close()
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+
+	for _, env := range []map[string]string{
+		{"TSHOCK_REST_TOKEN": token}, // as the template sets it
+		{},                           // read from the config file
+	} {
+		ep := game.Endpoint{IP: "127.0.0.1", Ports: map[string]string{"web": port}, Env: env, Files: files}
+		out, err := (&Adapter{}).Command(context.Background(), ep, "save")
+		if err != nil {
+			t.Fatalf("command with env %v: %v", env, err)
+		}
+		if out != "Saved" {
+			t.Errorf("output = %q, want Saved", out)
+		}
+	}
+}
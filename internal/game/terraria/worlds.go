@@ -0,0 +1,57 @@
+package terraria
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/storage"
+)
+
+// Worlds are .wld files at the top of the server's data, which the image
+// mounts as the Terraria worlds directory. WORLD_FILENAME names the one to
+// load; without it the server asks on the console.
+const envWorldFile = "WORLD_FILENAME"
+
+var worldName = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_ .'-]{0,63}$`)
+
+// Worlds lists the world files. The active one is WORLD_FILENAME.
+func (a *Adapter) Worlds(ctx context.Context, files storage.Volume, config map[string]string) ([]game.World, error) {
+	entries, err := files.ReadDir(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	worlds := []game.World{}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name, ".wld")
+		if e.IsDir || !ok {
+			continue
+		}
+		worlds = append(worlds, game.World{
+			Name:    name,
+			Size:    e.Size,
+			ModTime: e.ModTime,
+			Active:  e.Name == config[envWorldFile],
+		})
+	}
+	return worlds, nil
+}
+
+// SelectWorld loads an existing world file on the next start.
+func (a *Adapter) SelectWorld(ctx context.Context, files storage.Volume, name string) (map[string]string, error) {
+	if !worldName.MatchString(name) {
+		return nil, fmt.Errorf("invalid world name %q", name)
+	}
+	entries, err := files.ReadDir(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if !e.IsDir && e.Name == name+".wld" {
+			return map[string]string{envWorldFile: e.Name}, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", game.ErrUnknownWorld, name)
+}
@@ -0,0 +1,193 @@
+// Package tshock is a client for the REST API of TShock, the Terraria
+// server mod.
+package tshock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const timeout = 10 * time.Second
+
+// ErrAuth is returned when TShock rejects the token.
+var ErrAuth = errors.New("tshock: invalid token")
+
+// Client calls the REST API at BaseURL, e.g. "http://10.0.0.2:7878", with an
+// application token from TShock's ApplicationRestTokens setting.
+type Client struct {
+	BaseURL string
+	Token   string
+	HTTP    *http.Client // default http.DefaultClient
+}
+
+// Player is an online player as listed by the server.
+type Player struct {
+	Nickname string `json:"nickname"`
+	Username string `json:"username"`
+	Group    string `json:"group"`
+	Active   bool   `json:"active"`
+}
+
+// Ban is an entry of TShock's ban list. Identifiers are prefixed with their
+// kind, e.g. "name:Bob", "acc:Bob", "uuid:..." or "ip:...".
+type Ban struct {
+	Ticket     int    `json:"ticket_number"`
+	Identifier string `json:"identifier"`
+	Reason     string `json:"reason"`
+	BannedBy   string `json:"banning_user"`
+}
+
+// ServerStatus is the server's answer to /v2/server/status.
+type ServerStatus struct {
+	Name          string `json:"name"`
+	ServerVersion string `json:"serverversion"`
+	TShockVersion string `json:"tshockversion"`
+	World         string `json:"world"`
+	PlayerCount   int    `json:"playercount"`
+	MaxPlayers    int    `json:"maxplayers"`
+}
+
+// Status returns the server's name, versions and player counts.
+func (c *Client) Status(ctx context.Context) (*ServerStatus, error) {
+	var resp ServerStatus
+	if err := c.call(ctx, "/v2/server/status", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Players lists the players on the server.
+func (c *Client) Players(ctx context.Context) ([]Player, error) {
+	var resp struct {
+		Players []Player `json:"players"`
+	}
+	if err := c.call(ctx, "/v2/players/list", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Players, nil
+}
+
+// Kick disconnects an online player.
+func (c *Client) Kick(ctx context.Context, player, reason string) error {
+	q := url.Values{"player": {player}}
+	if reason != "" {
+		q.Set("reason", reason)
+	}
+	return c.call(ctx, "/v2/players/kick", q, nil)
+}
+
+// Ban adds a permanent ban for an identifier such as "name:Bob".
+func (c *Client) Ban(ctx context.Context, identifier, reason string) error {
+	q := url.Values{"identifier": {identifier}}
+	if reason != "" {
+		q.Set("reason", reason)
+	}
+	return c.call(ctx, "/v3/bans/create", q, nil)
+}
+
+// Bans lists the ban list.
+func (c *Client) Bans(ctx context.Context) ([]Ban, error) {
+	var resp struct {
+		Bans []Ban `json:"bans"`
+	}
+	if err := c.call(ctx, "/v3/bans/list", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Bans, nil
+}
+
+// Unban removes a ban by its ticket number.
+func (c *Client) Unban(ctx context.Context, ticket int) error {
+	q := url.Values{"ticketNumber": {fmt.Sprint(ticket)}, "fullDelete": {"true"}}
+	return c.call(ctx, "/v3/bans/destroy", q, nil)
+}
+
+// Broadcast sends a message to every player.
+func (c *Client) Broadcast(ctx context.Context, message string) error {
+	return c.call(ctx, "/v2/server/broadcast", url.Values{"msg": {message}}, nil)
+}
+
+// Command runs a console command as the server and returns its output.
+// Commands without the leading slash get one.
+func (c *Client) Command(ctx context.Context, command string) (string, error) {
+	if !strings.HasPrefix(command, "/") {
+		command = "/" + command
+	}
+	var resp struct {
+		Response json.RawMessage `json:"response"`
+	}
+	if err := c.call(ctx, "/v3/server/rawcmd", url.Values{"cmd": {command}}, &resp); err != nil {
+		return "", err
+	}
+	// Output is a list of lines, or a string on older versions
+	var lines []string
+	if json.Unmarshal(resp.Response, &lines) == nil {
+		return strings.Join(lines, "\n"), nil
+	}
+	var s string
+	json.Unmarshal(resp.Response, &s)
+	return s, nil
+}
+
+// call makes a request and decodes the response into out, if given. TShock
+// reports errors in the body, with a status field that mirrors HTTP codes.
+func (c *Client) call(ctx context.Context, path string, query url.Values, out any) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("token", c.Token)
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(c.BaseURL, "/")+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Leave out the URL, which holds the token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("tshock: %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	if err != nil {
+		return fmt.Errorf("tshock: %w", err)
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("tshock: %s: unexpected response (HTTP %d)", path, resp.StatusCode)
+	}
+	switch {
+	case result.Status == "403" || resp.StatusCode == http.StatusForbidden:
+		return ErrAuth
+	case result.Status != "" && result.Status != "200", resp.StatusCode != http.StatusOK:
+		msg := result.Error
+		if msg == "" {
+			msg = fmt.Sprintf("HTTP %d", resp.StatusCode)
+		}
+		return fmt.Errorf("tshock: %s", msg)
+	}
+	if out != nil {
+		return json.Unmarshal(body, out)
+	}
+	return nil
+}
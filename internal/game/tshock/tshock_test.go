@@ -0,0 +1,135 @@
+package tshock
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testToken = "s3cret-token"
+
+func TestCall(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error  // matched with errors.Is
+		wantMsg string // substring of the error
+	}{
+		{
+			name:   "ok",
+			status: http.StatusOK,
+			body:   `{"status":"200","response":"done"}`,
+		},
+		{
+			name:   "ok without a status field",
+			status: http.StatusOK,
+			body:   `{"players":[]}`,
+		},
+		{
+			name:    "token rejected in the body",
+			status:  http.StatusOK,
+			body:    `{"status":"403","error":"Not authorized. The specified API endpoint requires a token."}`,
+			wantErr: ErrAuth,
+		},
+		{
+			name:    "token rejected by status code",
+			status:  http.StatusForbidden,
+			body:    `{"error":"forbidden"}`,
+			wantErr: ErrAuth,
+		},
+		{
+			name:    "error in the body",
+			status:  http.StatusOK,
+			body:    `{"status":"400","error":"Missing or empty player parameter"}`,
+			wantMsg: "Missing or empty player parameter",
+		},
+		{
+			name:    "HTTP error without a message",
+			status:  http.StatusNotFound,
+			body:    `{}`,
+			wantMsg: "HTTP 404",
+		},
+		{
+			name:    "not JSON",
+			status:  http.StatusInternalServerError,
+			body:    `<html>oops</html>`,
+			wantMsg: "unexpected response (HTTP 500)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v2/server/broadcast" || r.URL.Query().Get("token") != testToken {
+					t.Errorf("unexpected request %s", r.URL)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := &Client{BaseURL: srv.URL, Token: testToken}
+			err := c.Broadcast(context.Background(), "hello")
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantMsg != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantMsg) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantMsg)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestCallHidesToken(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	c := &Client{BaseURL: srv.URL, Token: testToken}
+	err := c.Broadcast(context.Background(), "hello")
+	if err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+	if strings.Contains(err.Error(), testToken) {
+		t.Errorf("error leaks the token: %v", err)
+	}
+}
+
+func TestCommandOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"lines", `{"status":"200","response":["Online players (2/8):","Alice, Bob"]}`, "Online players (2/8):\nAlice, Bob"},
+		{"string", `{"status":"200","response":"Saved"}`, "Saved"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.URL.Query().Get("cmd"); got != "/who" {
+					t.Errorf("cmd = %q, want /who", got)
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			out, err := (&Client{BaseURL: srv.URL, Token: testToken}).Command(context.Background(), "who")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != tt.want {
+				t.Errorf("output = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,131 @@
+// Package valheim is the adapter for Valheim servers run from the
+// lloesche/valheim-server image.
+package valheim
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/game/a2s"
+)
+
+func init() {
+	game.Register(&Adapter{})
+}
+
+type Adapter struct{}
+
+var (
+	// 02/14/2024 18:00:00: Got character ZDOID from Bob : -123456:1
+	joinRe = regexp.MustCompile(`Got character ZDOID from (.+) : (-?\d+):(\d+)`)
+	// 02/14/2024 18:05:00: Destroying abandoned non persistent zdo -123456:3 owner -123456
+	leaveRe = regexp.MustCompile(`Destroying abandoned non persistent zdo -?\d+:\d+ owner (-?\d+)`)
+)
+
+func (a *Adapter) Game() string { return "valheim" }
+
+// ParseLogLine parses a line on its own, so leaves go unnamed; the players
+// tracker uses NewLogParser.
+func (a *Adapter) ParseLogLine(line string) *game.LogEvent {
+	return (&logParser{names: make(map[string]string)}).parse(line)
+}
+
+// NewLogParser returns a parser for one server's log. The leave line names
+// the player's session ID only, so names are remembered from the join line.
+func (a *Adapter) NewLogParser() func(line string) *game.LogEvent {
+	return (&logParser{names: make(map[string]string)}).parse
+}
+
+type logParser struct {
+	names map[string]string // session ID -> character name
+}
+
+func (p *logParser) parse(line string) *game.LogEvent {
+	if m := joinRe.FindStringSubmatch(line); m != nil {
+		// A ZDOID of 0:0 is logged when a character dies
+		if m[2] == "0" {
+			return nil
+		}
+		_, seen := p.names[m[2]]
+		p.names[m[2]] = m[1]
+		// The line repeats when a character respawns
+		if seen {
+			return nil
+		}
+		return &game.LogEvent{Type: "player_join", Player: m[1]}
+	}
+	if m := leaveRe.FindStringSubmatch(line); m != nil {
+		name, ok := p.names[m[1]]
+		delete(p.names, m[1])
+		if !ok {
+			return nil
+		}
+		return &game.LogEvent{Type: "player_leave", Player: name}
+	}
+	if strings.Contains(line, "Exception") || strings.Contains(line, "Error") {
+		return &game.LogEvent{Type: "error", Message: line}
+	}
+	return nil
+}
+
+// Valheim has no console; the image stops the server on SIGTERM.
+func (a *Adapter) PlayerCommand() string { return "" }
+func (a *Adapter) StopCommand() string   { return "" }
+
+// queryAddr returns the Steam query address. Valheim answers A2S queries on
+// the port after the game port.
+func queryAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return addr
+	}
+	return net.JoinHostPort(host, strconv.Itoa(n+1))
+}
+
+// Probe reports whether the server answers A2S_INFO.
+func (a *Adapter) Probe(ctx context.Context, addr string) error {
+	_, err := (&a2s.Client{Addr: queryAddr(addr)}).Info(ctx)
+	return err
+}
+
+// Status queries A2S_INFO and A2S_PLAYER.
+func (a *Adapter) Status(ctx context.Context, addr string) (*game.Status, error) {
+	client := &a2s.Client{Addr: queryAddr(addr)}
+	sent := time.Now()
+	info, err := client.Info(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := &game.Status{
+		Version: info.Version,
+		MOTD:    info.Name,
+		Online:  info.Players,
+		Max:     info.MaxPlayers,
+		Latency: time.Since(sent).Milliseconds(),
+	}
+	if players, err := client.Players(ctx); err == nil {
+		for _, p := range players {
+			if p.Name != "" {
+				status.Players = append(status.Players, p.Name)
+			}
+		}
+	}
+	return status, nil
+}
+
+func (a *Adapter) ConfigFiles() []game.ConfigFile {
+	return []game.ConfigFile{
+		{Path: "adminlist.txt", Format: "text", Description: "Steam IDs of admins"},
+		{Path: "bannedlist.txt", Format: "text", Description: "Steam IDs of banned players"},
+		{Path: "permittedlist.txt", Format: "text", Description: "Steam IDs allowed to join; empty allows everyone"},
+	}
+}
@@ -0,0 +1,74 @@
+package valheim
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/reedfamily/reedout/internal/game"
+)
+
+const (
+	bobJoins   = "02/14/2024 18:00:00: Got character ZDOID from Bob : -123456:1"
+	bobDies    = "02/14/2024 18:01:00: Got character ZDOID from Bob : 0:0"
+	bobRespawn = "02/14/2024 18:01:10: Got character ZDOID from Bob : -123456:1"
+	bobLeaves  = "02/14/2024 18:05:00: Destroying abandoned non persistent zdo -123456:3 owner -123456"
+)
+
+func TestLogParser(t *testing.T) {
+	join := &game.LogEvent{Type: "player_join", Player: "Bob"}
+	leave := &game.LogEvent{Type: "player_leave", Player: "Bob"}
+
+	tests := []struct {
+		name  string
+		lines []string
+		want  []*game.LogEvent
+	}{
+		{
+			name:  "join and leave",
+			lines: []string{bobJoins, bobLeaves},
+			want:  []*game.LogEvent{join, leave},
+		},
+		{
+			name:  "death and respawn",
+			lines: []string{bobJoins, bobDies, bobRespawn, bobLeaves},
+			want:  []*game.LogEvent{join, nil, nil, leave},
+		},
+		{
+			name:  "leave without a join",
+			lines: []string{bobLeaves},
+			want:  []*game.LogEvent{nil},
+		},
+		{
+			name:  "rejoin after leaving",
+			lines: []string{bobJoins, bobLeaves, bobJoins},
+			want:  []*game.LogEvent{join, leave, join},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parse := (&Adapter{}).NewLogParser()
+			var got []*game.LogEvent
+			for _, line := range tt.lines {
+				got = append(got, parse(line))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogParsersAreSeparate(t *testing.T) {
+	a := &Adapter{}
+	first, second := a.NewLogParser(), a.NewLogParser()
+	first(bobJoins)
+
+	// Another server's log reusing the session ID doesn't see Bob
+	if ev := second(bobLeaves); ev != nil {
+		t.Errorf("second server reported %+v", ev)
+	}
+	if ev := first(bobLeaves); ev == nil || ev.Player != "Bob" {
+		t.Errorf("first server reported %+v, want Bob leaving", ev)
+	}
+}
@@ -0,0 +1,102 @@
+package valheim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/storage"
+)
+
+// The server reads these lists of Steam IDs, one per line, on start and
+// when they change. Lines starting with // are comments.
+var listFiles = map[string]string{
+	"admins":    "adminlist.txt",
+	"bans":      "bannedlist.txt",
+	"permitted": "permittedlist.txt",
+}
+
+var playerID = regexp.MustCompile(`^[A-Za-z0-9_:-]{1,64}$`)
+
+func (a *Adapter) PlayerLists() []string {
+	return []string{"admins", "bans", "permitted"}
+}
+
+func (a *Adapter) ReadPlayerList(ctx context.Context, files storage.Volume, list string) ([]game.ListEntry, error) {
+	lines, err := readList(ctx, files, list)
+	if err != nil {
+		return nil, err
+	}
+	entries := []game.ListEntry{}
+	for _, line := range lines {
+		if id := strings.TrimSpace(line); id != "" && !strings.HasPrefix(id, "//") {
+			entries = append(entries, game.ListEntry{ID: id})
+		}
+	}
+	return entries, nil
+}
+
+// AddToPlayerList adds a Steam ID to a list. The server picks up the change
+// itself, so ep is not used.
+func (a *Adapter) AddToPlayerList(ctx context.Context, files storage.Volume, ep *game.Endpoint, list string, entry game.ListEntry) error {
+	if !playerID.MatchString(entry.ID) {
+		return fmt.Errorf("invalid Steam ID %q", entry.ID)
+	}
+	lines, err := readList(ctx, files, list)
+	if err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if strings.TrimSpace(line) == entry.ID {
+			return nil
+		}
+	}
+	return writeList(ctx, files, list, append(lines, entry.ID))
+}
+
+func (a *Adapter) RemoveFromPlayerList(ctx context.Context, files storage.Volume, ep *game.Endpoint, list, player string) error {
+	lines, err := readList(ctx, files, list)
+	if err != nil {
+		return err
+	}
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.TrimSpace(line) != player {
+			kept = append(kept, line)
+		}
+	}
+	return writeList(ctx, files, list, kept)
+}
+
+// readList returns the lines of a list file, comments included, so they
+// survive a rewrite.
+func readList(ctx context.Context, files storage.Volume, list string) ([]string, error) {
+	path, ok := listFiles[list]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", game.ErrUnknownList, list)
+	}
+	data, err := files.ReadFile(ctx, path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimRight(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if text == "" {
+		return nil, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
+func writeList(ctx context.Context, files storage.Volume, list string, lines []string) error {
+	data := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		data += "\n"
+	}
+	return files.WriteFile(ctx, listFiles[list], []byte(data))
+}
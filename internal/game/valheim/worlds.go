@@ -0,0 +1,108 @@
+package valheim
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strings"
+
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/storage"
+)
+
+// A world is a .fwl metadata file and a .db file of the same name. Recent
+// versions keep them in worlds_local/, older ones in worlds/. The server
+// loads WORLD_NAME and creates it if it doesn't exist.
+const (
+	envWorldName = "WORLD_NAME"
+	defaultWorld = "Dedicated"
+)
+
+var worldDirs = []string{"worlds_local", "worlds"}
+
+var worldName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Worlds lists the worlds in the world directory. The active one is
+// WORLD_NAME.
+func (a *Adapter) Worlds(ctx context.Context, files storage.Volume, config map[string]string) ([]game.World, error) {
+	active := config[envWorldName]
+	if active == "" {
+		active = defaultWorld
+	}
+	worlds := []game.World{}
+	seen := make(map[string]bool)
+	for _, dir := range worldDirs {
+		entries, err := files.ReadDir(ctx, dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Sizes and times come from the .db file, which holds the world
+		sizes := make(map[string]storage.FileInfo)
+		for _, e := range entries {
+			if name, ok := strings.CutSuffix(e.Name, ".db"); ok && !e.IsDir {
+				sizes[name] = e
+			}
+		}
+		for _, e := range entries {
+			name, ok := strings.CutSuffix(e.Name, ".fwl")
+			if e.IsDir || !ok || seen[name] {
+				continue
+			}
+			seen[name] = true
+			w := game.World{Name: name, ModTime: e.ModTime, Active: name == active}
+			if db, ok := sizes[name]; ok {
+				w.Size = db.Size
+				w.ModTime = db.ModTime
+			}
+			worlds = append(worlds, w)
+		}
+	}
+	return worlds, nil
+}
+
+// SelectWorld loads an existing world on the next start.
+func (a *Adapter) SelectWorld(ctx context.Context, files storage.Volume, name string) (map[string]string, error) {
+	if !worldName.MatchString(name) {
+		return nil, fmt.Errorf("invalid world name %q", name)
+	}
+	if !a.exists(ctx, files, name) {
+		return nil, fmt.Errorf("%w: %s", game.ErrUnknownWorld, name)
+	}
+	return map[string]string{envWorldName: name}, nil
+}
+
+// CreateWorld generates a new world on the next start. Valheim picks a
+// random seed and takes no other settings.
+func (a *Adapter) CreateWorld(ctx context.Context, files storage.Volume, name string, settings json.RawMessage) (map[string]string, error) {
+	if !worldName.MatchString(name) {
+		return nil, fmt.Errorf("invalid world name %q", name)
+	}
+	if s := strings.TrimSpace(string(settings)); s != "" && s != "null" && s != "{}" {
+		return nil, errors.New("valheim worlds take no settings")
+	}
+	if a.exists(ctx, files, name) {
+		return nil, fmt.Errorf("%w: %s", game.ErrWorldExists, name)
+	}
+	return map[string]string{envWorldName: name}, nil
+}
+
+func (a *Adapter) exists(ctx context.Context, files storage.Volume, name string) bool {
+	for _, dir := range worldDirs {
+		entries, err := files.ReadDir(ctx, dir)
+		if err != nil {
+			continue
+		}
+		for _, e := range entries {
+			if !e.IsDir && e.Name == name+".fwl" {
+				return true
+			}
+		}
+	}
+	return false
+}
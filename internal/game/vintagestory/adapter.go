@@ -35,3 +35,9 @@ func (a *Adapter) ParseLogLine(line string) *game.LogEvent {
 
 func (a *Adapter) PlayerCommand() string { return "/list" }
 func (a *Adapter) StopCommand() string   { return "/stop" }
+
+func (a *Adapter) ConfigFiles() []game.ConfigFile {
+	return []game.ConfigFile{
+		{Path: "serverconfig.json", Format: "json", Description: "Server settings"},
+	}
+}
@@ -0,0 +1,357 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/game"
+)
+
+const (
+	Starting  = "starting"
+	Healthy   = "healthy"
+	Unhealthy = "unhealthy"
+)
+
+// statusInterval is how often running servers' game status is queried.
+const statusInterval = 15 * time.Second
+
+// Monitor runs panel-side health probes (tcp and query checks) for running
+// servers. Command checks are run by Docker and read back from the container.
+// It also polls the game status of servers whose adapter can report one.
+type Monitor struct {
+	db     *sql.DB
+	docker *docker.Client
+
+	mu       sync.RWMutex
+	state    map[string]*probeState  // server_id -> probe state
+	statuses map[string]*game.Status // server_id -> last game status
+
+	cancel context.CancelFunc
+}
+
+type probeState struct {
+	startedAt string // container start time the state belongs to
+	status    string
+	failures  int
+	lastProbe time.Time
+	lastError string
+}
+
+func NewMonitor(db *sql.DB, dockerClient *docker.Client) *Monitor {
+	return &Monitor{
+		db:       db,
+		docker:   dockerClient,
+		state:    make(map[string]*probeState),
+		statuses: make(map[string]*game.Status),
+	}
+}
+
+func (m *Monitor) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(2 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.probeAll(ctx)
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(statusInterval)
+		defer ticker.Stop()
+
+		m.pollStatus(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.pollStatus(ctx)
+			}
+		}
+	}()
+
+	log.Println("Health monitor started")
+}
+
+func (m *Monitor) Stop() {
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
+// Status returns the health of a server: starting, healthy or unhealthy.
+// It is empty when the server has no health check or is not running.
+func (m *Monitor) Status(ctx context.Context, serverID, containerID string, hc *docker.HealthCheck) string {
+	if hc == nil || containerID == "" {
+		return ""
+	}
+	inspect, err := m.docker.InspectContainer(ctx, containerID)
+	if err != nil || inspect.State == nil || !inspect.State.Running {
+		return ""
+	}
+	if hc.Type == docker.HealthCommand {
+		if inspect.State.Health == nil {
+			return Starting
+		}
+		return inspect.State.Health.Status
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	st := m.state[serverID]
+	if st == nil || st.startedAt != inspect.State.StartedAt {
+		return Starting
+	}
+	return st.status
+}
+
+func (m *Monitor) probeAll(ctx context.Context) {
+	rows, err := m.db.Query("SELECT id, game, container_id, health_check, ports FROM servers WHERE status = 'running' AND container_id != '' AND health_check != ''")
+	if err != nil {
+		log.Printf("health: query servers: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	type target struct {
+		id          string
+		game        string
+		containerID string
+		check       docker.HealthCheck
+	}
+	var targets []target
+	for rows.Next() {
+		var t target
+		var checkJSON, portsJSON string
+		if err := rows.Scan(&t.id, &t.game, &t.containerID, &checkJSON, &portsJSON); err != nil {
+			continue
+		}
+		if json.Unmarshal([]byte(checkJSON), &t.check) != nil || t.check.Type == docker.HealthCommand {
+			continue
+		}
+		if t.check.Port == "" {
+			// Probe the port adapters query: the query port, else the game port
+			var ports []docker.PortMapping
+			json.Unmarshal([]byte(portsJSON), &ports)
+			docker.NamePorts(ports)
+			p := docker.QueryPort(ports)
+			if p == nil {
+				continue
+			}
+			t.check.Port = docker.FirstPort(p.Container)
+		}
+		targets = append(targets, t)
+	}
+	rows.Close()
+
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.probeServer(ctx, t.id, t.game, t.containerID, &t.check)
+		}()
+	}
+	wg.Wait()
+}
+
+func (m *Monitor) probeServer(ctx context.Context, serverID, gameID, containerID string, hc *docker.HealthCheck) {
+	inspect, err := m.docker.InspectContainer(ctx, containerID)
+	if err != nil || inspect.State == nil || !inspect.State.Running {
+		m.mu.Lock()
+		delete(m.state, serverID)
+		m.mu.Unlock()
+		return
+	}
+	startedAt := inspect.State.StartedAt
+
+	m.mu.Lock()
+	st := m.state[serverID]
+	if st == nil || st.startedAt != startedAt {
+		// Container (re)started: begin again from "starting"
+		st = &probeState{startedAt: startedAt, status: Starting}
+		m.state[serverID] = st
+	}
+	due := time.Since(st.lastProbe) >= hc.IntervalDuration()
+	m.mu.Unlock()
+	if !due {
+		return
+	}
+
+	probeErr := m.probe(ctx, gameID, containerID, hc)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st.lastProbe = time.Now()
+	if probeErr == nil {
+		st.status = Healthy
+		st.failures = 0
+		st.lastError = ""
+		return
+	}
+	st.lastError = probeErr.Error()
+
+	// As with Docker, failures during the start period don't count unless
+	// the server has already been healthy.
+	started, _ := time.Parse(time.RFC3339Nano, startedAt)
+	if st.status == Starting && time.Since(started) < hc.StartPeriodDuration() {
+		return
+	}
+	st.failures++
+	if st.failures >= hc.RetryCount() {
+		if st.status != Unhealthy {
+			log.Printf("health: server %s is unhealthy: %v", serverID, probeErr)
+		}
+		st.status = Unhealthy
+	}
+}
+
+func (m *Monitor) probe(ctx context.Context, gameID, containerID string, hc *docker.HealthCheck) error {
+	ctx, cancel := context.WithTimeout(ctx, hc.TimeoutDuration())
+	defer cancel()
+
+	ip, err := m.docker.ContainerIP(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	addr := net.JoinHostPort(ip, hc.Port)
+
+	if hc.Type == docker.HealthQuery {
+		if p, ok := game.Get(gameID).(game.Prober); ok {
+			return p.Probe(ctx, addr)
+		}
+		// Adapters without a status query fall back to a plain TCP probe
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// GameStatus returns the last game status of a running server, or nil if
+// its adapter can't report one or it hasn't been queried yet.
+func (m *Monitor) GameStatus(serverID string) *game.Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if st, ok := m.statuses[serverID]; ok {
+		c := *st
+		return &c
+	}
+	return nil
+}
+
+func (m *Monitor) pollStatus(ctx context.Context) {
+	rows, err := m.db.Query("SELECT id, game, container_id, ports, env FROM servers WHERE status = 'running' AND container_id != ''")
+	if err != nil {
+		log.Printf("health: query servers: %v", err)
+		return
+	}
+	type target struct {
+		id          string
+		containerID string
+		port        string
+		querier     game.StatusQuerier
+		// Set instead of port and querier for adapters that query an endpoint
+		ep        game.Endpoint
+		epQuerier game.EndpointStatusQuerier
+	}
+	var targets []target
+	for rows.Next() {
+		var t target
+		var gameID, portsJSON, envJSON string
+		if err := rows.Scan(&t.id, &gameID, &t.containerID, &portsJSON, &envJSON); err != nil {
+			continue
+		}
+		var ports []docker.PortMapping
+		json.Unmarshal([]byte(portsJSON), &ports)
+		docker.NamePorts(ports)
+
+		adapter := game.Get(gameID)
+		if epQuerier, ok := adapter.(game.EndpointStatusQuerier); ok {
+			t.ep = game.Endpoint{Ports: map[string]string{}, Env: map[string]string{}}
+			for _, p := range ports {
+				if p.Role != "" && t.ep.Ports[p.Role] == "" {
+					t.ep.Ports[p.Role] = docker.FirstPort(p.Container)
+				}
+			}
+			json.Unmarshal([]byte(envJSON), &t.ep.Env)
+			t.epQuerier = epQuerier
+			targets = append(targets, t)
+			continue
+		}
+		querier, ok := adapter.(game.StatusQuerier)
+		if !ok {
+			continue
+		}
+		p := docker.QueryPort(ports)
+		if p == nil {
+			continue
+		}
+		t.port, t.querier = docker.FirstPort(p.Container), querier
+		targets = append(targets, t)
+	}
+	rows.Close()
+
+	running := make(map[string]bool, len(targets))
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		running[t.id] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			qctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+
+			st := &game.Status{}
+			ip, err := m.docker.ContainerIP(qctx, t.containerID)
+			if err == nil {
+				var res *game.Status
+				if t.epQuerier != nil {
+					t.ep.IP = ip
+					res, err = t.epQuerier.EndpointStatus(qctx, t.ep)
+				} else {
+					res, err = t.querier.Status(qctx, net.JoinHostPort(ip, t.port))
+				}
+				if err == nil {
+					st = res
+				}
+			}
+			if err != nil {
+				st.Error = err.Error()
+			}
+			st.CheckedAt = time.Now().UTC()
+
+			m.mu.Lock()
+			m.statuses[t.id] = st
+			m.mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	for id := range m.statuses {
+		if !running[id] {
+			delete(m.statuses, id)
+		}
+	}
+	m.mu.Unlock()
+}
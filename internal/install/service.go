@@ -0,0 +1,298 @@
+package install
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/storage"
+)
+
+const (
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+)
+
+// installTimeout bounds a single install run.
+const installTimeout = 30 * time.Minute
+
+// Install is one run of a server's install script.
+type Install struct {
+	ID         string `json:"id"`
+	ServerID   string `json:"server_id"`
+	Status     string `json:"status"` // running, success, failed
+	ExitCode   int64  `json:"exit_code"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at,omitempty"`
+}
+
+type Service struct {
+	db      *sql.DB
+	docker  *docker.Client
+	storage *storage.Service
+	dataDir string
+
+	mu   sync.Mutex
+	runs map[string]*run // server_id -> in-progress install
+}
+
+// run fans an installer's output out to the log file and live subscribers.
+type run struct {
+	mu        sync.Mutex
+	file      *os.File
+	backlog   bytes.Buffer
+	listeners []chan []byte
+}
+
+func NewService(db *sql.DB, dockerClient *docker.Client, storageSvc *storage.Service, dataDir string) *Service {
+	return &Service{
+		db:      db,
+		docker:  dockerClient,
+		storage: storageSvc,
+		dataDir: dataDir,
+		runs:    make(map[string]*run),
+	}
+}
+
+// Recover marks installs left running by a previous panel process as failed.
+func (s *Service) Recover() error {
+	_, err := s.db.Exec(`UPDATE installs SET status = ?, error = 'interrupted by panel restart', finished_at = ? WHERE status = ?`,
+		StatusFailed, time.Now().UTC(), StatusRunning)
+	return err
+}
+
+// logPath returns where an install's output is kept.
+func (s *Service) logPath(serverID, installID string) string {
+	return filepath.Join(s.dataDir, "installs", serverID, installID+".log")
+}
+
+// Run starts a server's install script in the background. Only one install
+// per server can run at a time.
+func (s *Service) Run(serverID string) (*Install, error) {
+	var envJSON, installJSON, securityJSON string
+	err := s.db.QueryRow(`SELECT env, install, security FROM servers WHERE id = ?`, serverID).Scan(&envJSON, &installJSON, &securityJSON)
+	if err != nil {
+		return nil, fmt.Errorf("server not found: %w", err)
+	}
+	if installJSON == "" {
+		return nil, fmt.Errorf("server has no install script")
+	}
+	var cfg docker.InstallConfig
+	if err := json.Unmarshal([]byte(installJSON), &cfg); err != nil {
+		return nil, fmt.Errorf("parse install config: %w", err)
+	}
+	var env map[string]string
+	json.Unmarshal([]byte(envJSON), &env)
+
+	vol, err := s.storage.ForServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if _, busy := s.runs[serverID]; busy {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("an install is already running")
+	}
+
+	inst := &Install{
+		ID:        uuid.New().String()[:8],
+		ServerID:  serverID,
+		Status:    StatusRunning,
+		ExitCode:  -1,
+		StartedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	logPath := s.logPath(serverID, inst.ID)
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("create install log directory: %w", err)
+	}
+	file, err := os.Create(logPath)
+	if err != nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("create install log: %w", err)
+	}
+	rn := &run{file: file}
+	s.runs[serverID] = rn
+	s.mu.Unlock()
+
+	_, err = s.db.Exec(`INSERT INTO installs (id, server_id, status, exit_code) VALUES (?, ?, ?, ?)`,
+		inst.ID, serverID, inst.Status, inst.ExitCode)
+	if err != nil {
+		s.finish(serverID, rn)
+		return nil, fmt.Errorf("save install record: %w", err)
+	}
+
+	go s.execute(inst, rn, cfg, env, vol, securityJSON)
+	return inst, nil
+}
+
+func (s *Service) execute(inst *Install, rn *run, cfg docker.InstallConfig, env map[string]string, vol storage.Volume, securityJSON string) {
+	defer s.finish(inst.ServerID, rn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), installTimeout)
+	defer cancel()
+
+	fmt.Fprintf(rn, "[ReedOut] Running install script in %s\n", cfg.Image)
+	exitCode, err := s.docker.RunInstaller(ctx, docker.InstallerSpec{
+		Name:   fmt.Sprintf("reedout-install-%s-%s", inst.ServerID, inst.ID),
+		Config: cfg,
+		Env:    env,
+		Source: vol.Source(),
+	}, rn)
+
+	// The installer runs as root; hand the files to an unprivileged game user
+	if err == nil && exitCode == 0 && securityJSON != "" {
+		var sec docker.SecurityProfile
+		json.Unmarshal([]byte(securityJSON), &sec)
+		if sec.User != "" {
+			uid, gid, _ := docker.ParseUser(sec.User)
+			if chownErr := vol.Chown(ctx, uid, gid); chownErr != nil {
+				fmt.Fprintf(rn, "[ReedOut] Warning: failed to chown data to %s: %v\n", sec.User, chownErr)
+			}
+		}
+	}
+
+	inst.ExitCode = exitCode
+	inst.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+	switch {
+	case err != nil:
+		inst.Status = StatusFailed
+		inst.Error = err.Error()
+		fmt.Fprintf(rn, "[ReedOut] Install failed: %v\n", err)
+	case exitCode != 0:
+		inst.Status = StatusFailed
+		inst.Error = fmt.Sprintf("install script exited with code %d", exitCode)
+		fmt.Fprintf(rn, "[ReedOut] %s\n", inst.Error)
+	default:
+		inst.Status = StatusSuccess
+		fmt.Fprintln(rn, "[ReedOut] Install complete")
+	}
+
+	_, dbErr := s.db.Exec(`UPDATE installs SET status = ?, exit_code = ?, error = ?, finished_at = ? WHERE id = ?`,
+		inst.Status, inst.ExitCode, inst.Error, time.Now().UTC(), inst.ID)
+	if dbErr != nil {
+		log.Printf("install: update %s: %v", inst.ID, dbErr)
+	}
+	log.Printf("install: server %s: %s", inst.ServerID, inst.Status)
+}
+
+func (s *Service) finish(serverID string, rn *run) {
+	s.mu.Lock()
+	delete(s.runs, serverID)
+	s.mu.Unlock()
+
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	rn.file.Close()
+	for _, ch := range rn.listeners {
+		close(ch)
+	}
+	rn.listeners = nil
+}
+
+// Latest returns the most recent install for a server, or nil if it has never run.
+func (s *Service) Latest(serverID string) (*Install, error) {
+	var inst Install
+	err := s.db.QueryRow(
+		`SELECT id, server_id, status, exit_code, error, started_at, COALESCE(finished_at, '')
+		FROM installs WHERE server_id = ? ORDER BY started_at DESC, rowid DESC LIMIT 1`, serverID,
+	).Scan(&inst.ID, &inst.ServerID, &inst.Status, &inst.ExitCode, &inst.Error, &inst.StartedAt, &inst.FinishedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &inst, nil
+}
+
+// Ready returns an error if the server's latest install is still running or
+// failed. Servers that were never installed are considered ready.
+func (s *Service) Ready(serverID string) error {
+	inst, err := s.Latest(serverID)
+	if err != nil || inst == nil {
+		return err
+	}
+	switch inst.Status {
+	case StatusRunning:
+		return fmt.Errorf("server is still installing")
+	case StatusFailed:
+		return fmt.Errorf("install failed; check the install log and reinstall")
+	}
+	return nil
+}
+
+// Log returns the full output of an install.
+func (s *Service) Log(serverID, installID string) ([]byte, error) {
+	return os.ReadFile(s.logPath(serverID, installID))
+}
+
+// Subscribe returns the output of a running install so far plus a channel
+// carrying the rest. The channel is closed when the install finishes.
+// ok is false when no install is running.
+func (s *Service) Subscribe(serverID string) (backlog []byte, ch chan []byte, ok bool) {
+	s.mu.Lock()
+	rn := s.runs[serverID]
+	s.mu.Unlock()
+	if rn == nil {
+		return nil, nil, false
+	}
+
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	ch = make(chan []byte, 64)
+	rn.listeners = append(rn.listeners, ch)
+	return bytes.Clone(rn.backlog.Bytes()), ch, true
+}
+
+// Unsubscribe stops delivery to a channel returned by Subscribe.
+func (s *Service) Unsubscribe(serverID string, ch chan []byte) {
+	s.mu.Lock()
+	rn := s.runs[serverID]
+	s.mu.Unlock()
+	if rn == nil {
+		return
+	}
+
+	rn.mu.Lock()
+	defer rn.mu.Unlock()
+	for i, l := range rn.listeners {
+		if l == ch {
+			rn.listeners = append(rn.listeners[:i], rn.listeners[i+1:]...)
+			close(ch)
+			return
+		}
+	}
+}
+
+// Write implements io.Writer for installer output.
+func (r *run) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.file.Write(p)
+	r.backlog.Write(p)
+	chunk := bytes.Clone(p)
+	for _, ch := range r.listeners {
+		select {
+		case ch <- chunk:
+		default:
+			// Drop if listener is slow
+		}
+	}
+	return len(p), nil
+}
+
+var _ io.Writer = (*run)(nil)
@@ -0,0 +1,275 @@
+// Package players follows running servers' logs through their game adapter
+// and records player sessions.
+package players
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/game"
+)
+
+// timeFormat is fixed-width so stored times compare correctly as strings.
+const timeFormat = "2006-01-02 15:04:05.000"
+
+// Session is one stay of a player on a server. LeftAt is nil while the
+// player is online; Duration is in seconds, up to now for online players.
+type Session struct {
+	ID       int64      `json:"id"`
+	Player   string     `json:"player"`
+	JoinedAt time.Time  `json:"joined_at"`
+	LeftAt   *time.Time `json:"left_at"`
+	Duration int64      `json:"duration"`
+}
+
+// Tracker runs one log follower per running server.
+type Tracker struct {
+	db     *sql.DB
+	docker *docker.Client
+
+	mu        sync.Mutex
+	followers map[string]*follower // server_id -> follower
+
+	cancel context.CancelFunc
+}
+
+type follower struct {
+	containerID string
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+func NewTracker(db *sql.DB, dockerClient *docker.Client) *Tracker {
+	return &Tracker{
+		db:        db,
+		docker:    dockerClient,
+		followers: make(map[string]*follower),
+	}
+}
+
+func (t *Tracker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+
+		t.sync(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				t.sync(ctx)
+			}
+		}
+	}()
+
+	log.Println("Player tracker started")
+}
+
+func (t *Tracker) Stop() {
+	if t.cancel != nil {
+		t.cancel()
+	}
+}
+
+// sync starts followers for running servers and drops those whose server
+// stopped or whose container was replaced.
+func (t *Tracker) sync(ctx context.Context) {
+	rows, err := t.db.Query("SELECT id, game, container_id FROM servers WHERE status = 'running' AND container_id != ''")
+	if err != nil {
+		log.Printf("players: query servers: %v", err)
+		return
+	}
+	running := map[string][2]string{} // server_id -> game, container_id
+	for rows.Next() {
+		var id, gameID, containerID string
+		if err := rows.Scan(&id, &gameID, &containerID); err != nil {
+			continue
+		}
+		if game.Get(gameID) != nil {
+			running[id] = [2]string{gameID, containerID}
+		}
+	}
+	rows.Close()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, f := range t.followers {
+		select {
+		case <-f.done:
+			delete(t.followers, id)
+			continue
+		default:
+		}
+		if s, ok := running[id]; !ok || s[1] != f.containerID {
+			f.cancel()
+			delete(t.followers, id)
+		}
+	}
+	for id, s := range running {
+		if _, ok := t.followers[id]; ok {
+			continue
+		}
+		fctx, cancel := context.WithCancel(ctx)
+		f := &follower{containerID: s[1], cancel: cancel, done: make(chan struct{})}
+		t.followers[id] = f
+		go func() {
+			defer close(f.done)
+			if err := t.follow(fctx, id, s[0], s[1]); err != nil && fctx.Err() == nil {
+				log.Printf("players: follow %s: %v", id, err)
+			}
+		}()
+	}
+}
+
+// follow reads a container's logs from its start, skipping lines already
+// recorded, until the container stops.
+func (t *Tracker) follow(ctx context.Context, serverID, gameID, containerID string) error {
+	// The parser, and whatever it remembers between lines, ends with the log
+	parse := game.LogParser(game.Get(gameID))
+	inspect, err := t.docker.InspectContainer(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	if inspect.State == nil || !inspect.State.Running {
+		return nil
+	}
+	started, err := time.Parse(time.RFC3339Nano, inspect.State.StartedAt)
+	if err != nil {
+		return err
+	}
+
+	// Players still online from an earlier run left when it ended
+	t.db.Exec(`UPDATE player_sessions SET left_at = ? WHERE server_id = ? AND left_at IS NULL AND joined_at < ?`,
+		started.UTC().Format(timeFormat), serverID, started.UTC().Format(timeFormat))
+
+	// After a panel restart the logs are replayed; skip what was recorded
+	var lastJoin, lastLeave string
+	t.db.QueryRow(`SELECT COALESCE(MAX(joined_at), ''), COALESCE(MAX(left_at), '') FROM player_sessions WHERE server_id = ?`, serverID).
+		Scan(&lastJoin, &lastLeave)
+	last := max(lastJoin, lastLeave)
+
+	logs, err := t.docker.FollowLogs(ctx, containerID, started)
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	scanner := bufio.NewScanner(logs)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		stamp, line, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339Nano, stamp)
+		if err != nil {
+			continue
+		}
+		// Recorded lines are still parsed so adapters that track state across
+		// lines (Valheim maps connection IDs to names) can name later leaves.
+		// Lines in the same millisecond as the last recorded event are
+		// replayed; join and leave ignore events they already have.
+		when := at.UTC().Format(timeFormat)
+		ev := parse(strings.TrimRight(line, "\r"))
+		if when < last || ev == nil || ev.Player == "" {
+			continue
+		}
+		switch ev.Type {
+		case "player_join":
+			t.join(serverID, ev.Player, when)
+		case "player_leave":
+			t.leave(serverID, ev.Player, when)
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	// The log stream ends with the container; everyone still online left then
+	ended := time.Now().UTC()
+	if inspect, err := t.docker.InspectContainer(context.Background(), containerID); err == nil && inspect.State != nil {
+		if inspect.State.Running {
+			return scanner.Err()
+		}
+		if finished, err := time.Parse(time.RFC3339Nano, inspect.State.FinishedAt); err == nil && finished.After(started) {
+			ended = finished.UTC()
+		}
+	}
+	_, err = t.db.Exec(`UPDATE player_sessions SET left_at = ? WHERE server_id = ? AND left_at IS NULL`, ended.Format(timeFormat), serverID)
+	return err
+}
+
+func (t *Tracker) join(serverID, player, at string) {
+	var known int
+	t.db.QueryRow(`SELECT COUNT(*) FROM player_sessions WHERE server_id = ? AND player = ? AND (left_at IS NULL OR joined_at = ?)`,
+		serverID, player, at).Scan(&known)
+	if known > 0 {
+		return
+	}
+	if _, err := t.db.Exec(`INSERT INTO player_sessions (server_id, player, joined_at) VALUES (?, ?, ?)`, serverID, player, at); err != nil {
+		log.Printf("players: record join on %s: %v", serverID, err)
+	}
+}
+
+func (t *Tracker) leave(serverID, player, at string) {
+	var recorded int
+	t.db.QueryRow(`SELECT COUNT(*) FROM player_sessions WHERE server_id = ? AND player = ? AND left_at = ?`, serverID, player, at).Scan(&recorded)
+	if recorded > 0 {
+		return
+	}
+	if _, err := t.db.Exec(`UPDATE player_sessions SET left_at = ? WHERE server_id = ? AND player = ? AND left_at IS NULL`, at, serverID, player); err != nil {
+		log.Printf("players: record leave on %s: %v", serverID, err)
+	}
+}
+
+// Online returns the sessions of players currently on a server.
+func (t *Tracker) Online(serverID string) ([]Session, error) {
+	return t.query(`SELECT id, player, joined_at, left_at FROM player_sessions
+		WHERE server_id = ? AND left_at IS NULL ORDER BY joined_at`, serverID)
+}
+
+// History returns a server's sessions, newest first, optionally for one player.
+func (t *Tracker) History(serverID, player string, limit int) ([]Session, error) {
+	if player != "" {
+		return t.query(`SELECT id, player, joined_at, left_at FROM player_sessions
+			WHERE server_id = ? AND player = ? ORDER BY joined_at DESC LIMIT ?`, serverID, player, limit)
+	}
+	return t.query(`SELECT id, player, joined_at, left_at FROM player_sessions
+		WHERE server_id = ? ORDER BY joined_at DESC LIMIT ?`, serverID, limit)
+}
+
+func (t *Tracker) query(q string, args ...any) ([]Session, error) {
+	rows, err := t.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now().UTC()
+	sessions := []Session{}
+	for rows.Next() {
+		var s Session
+		var left sql.NullTime
+		if err := rows.Scan(&s.ID, &s.Player, &s.JoinedAt, &left); err != nil {
+			return nil, err
+		}
+		end := now
+		if left.Valid {
+			end = left.Time
+			s.LeftAt = &left.Time
+		}
+		s.Duration = int64(end.Sub(s.JoinedAt).Seconds())
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
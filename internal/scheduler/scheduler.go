@@ -7,7 +7,9 @@ import (
 	"time"
 
 	"github.com/reedfamily/reedout/internal/backup"
+	"github.com/reedfamily/reedout/internal/configfile"
 	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/install"
 )
 
 type Schedule struct {
@@ -22,17 +24,21 @@ type Schedule struct {
 }
 
 type Scheduler struct {
-	db     *sql.DB
-	docker *docker.Client
-	backup *backup.Service
-	cancel context.CancelFunc
+	db       *sql.DB
+	docker   *docker.Client
+	backup   *backup.Service
+	installs *install.Service
+	configs  *configfile.Service
+	cancel   context.CancelFunc
 }
 
-func New(db *sql.DB, dockerClient *docker.Client, backupSvc *backup.Service) *Scheduler {
+func New(db *sql.DB, dockerClient *docker.Client, backupSvc *backup.Service, installSvc *install.Service, configSvc *configfile.Service) *Scheduler {
 	return &Scheduler{
-		db:     db,
-		docker: dockerClient,
-		backup: backupSvc,
+		db:       db,
+		docker:   dockerClient,
+		backup:   backupSvc,
+		installs: installSvc,
+		configs:  configSvc,
 	}
 }
 
@@ -120,6 +126,12 @@ func (s *Scheduler) execute(ctx context.Context, action, serverID, containerID s
 	var err error
 	switch action {
 	case "start":
+		if err = s.installs.Ready(serverID); err != nil {
+			break
+		}
+		if err = s.configs.Render(ctx, serverID); err != nil {
+			break
+		}
 		err = s.docker.StartContainer(ctx, containerID)
 		if err == nil {
 			s.db.Exec("UPDATE servers SET status = 'running', updated_at = ? WHERE id = ?", time.Now(), serverID)
@@ -130,6 +142,12 @@ func (s *Scheduler) execute(ctx context.Context, action, serverID, containerID s
 			s.db.Exec("UPDATE servers SET status = 'exited', updated_at = ? WHERE id = ?", time.Now(), serverID)
 		}
 	case "restart":
+		if err = s.installs.Ready(serverID); err != nil {
+			break
+		}
+		if err = s.configs.Render(ctx, serverID); err != nil {
+			break
+		}
 		err = s.docker.RestartContainer(ctx, containerID)
 		if err == nil {
 			s.db.Exec("UPDATE servers SET status = 'running', updated_at = ? WHERE id = ?", time.Now(), serverID)
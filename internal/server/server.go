@@ -12,15 +12,27 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/reedfamily/reedout/internal/api"
+	"github.com/reedfamily/reedout/internal/audit"
 	"github.com/reedfamily/reedout/internal/auth"
 	"github.com/reedfamily/reedout/internal/backup"
 	"github.com/reedfamily/reedout/internal/config"
+	"github.com/reedfamily/reedout/internal/configfile"
 	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/health"
+	"github.com/reedfamily/reedout/internal/install"
+	"github.com/reedfamily/reedout/internal/players"
 	"github.com/reedfamily/reedout/internal/scheduler"
 	"github.com/reedfamily/reedout/internal/stats"
+	"github.com/reedfamily/reedout/internal/storage"
+	"github.com/reedfamily/reedout/internal/templates"
 
 	// Register game adapters
+	_ "github.com/reedfamily/reedout/internal/game/bedrock"
+	_ "github.com/reedfamily/reedout/internal/game/factorio"
 	_ "github.com/reedfamily/reedout/internal/game/minecraft"
+	_ "github.com/reedfamily/reedout/internal/game/source"
+	_ "github.com/reedfamily/reedout/internal/game/terraria"
+	_ "github.com/reedfamily/reedout/internal/game/valheim"
 	_ "github.com/reedfamily/reedout/internal/game/vintagestory"
 )
 
@@ -30,6 +42,8 @@ type Server struct {
 	router    chi.Router
 	collector *stats.Collector
 	scheduler *scheduler.Scheduler
+	health    *health.Monitor
+	players   *players.Tracker
 }
 
 func New(cfg *config.Config, db *sql.DB) (*Server, error) {
@@ -44,32 +58,63 @@ func New(cfg *config.Config, db *sql.DB) (*Server, error) {
 	if err != nil {
 		return nil, fmt.Errorf("docker client: %w", err)
 	}
+	dockerClient.HelperImage = cfg.HelperImage
 
-	// Load templates
-	templates, err := docker.LoadTemplates(cfg.TemplatePath)
-	if err != nil {
+	// Load templates, seeding the store from the template directory
+	templateStore := templates.NewStore(db, cfg.TemplatePath)
+	if err := templateStore.Seed(); err != nil {
 		log.Printf("Warning: failed to load templates: %v", err)
-		templates = []docker.GameTemplate{}
 	}
 
 	// Start stats collector
 	collector := stats.NewCollector(db, dockerClient)
 	collector.Start()
 
+	// Start health monitor
+	healthMonitor := health.NewMonitor(db, dockerClient)
+	healthMonitor.Start()
+
+	// Start player tracker
+	playerTracker := players.NewTracker(db, dockerClient)
+	playerTracker.Start()
+
+	// Server data access (host directories or named volumes)
+	storageSvc := storage.NewService(db, dockerClient, cfg.DataDir)
+
+	auditSvc := audit.NewService(db)
+
+	installSvc := install.NewService(db, dockerClient, storageSvc, cfg.DataDir)
+	if err := installSvc.Recover(); err != nil {
+		log.Printf("Warning: failed to recover installs: %v", err)
+	}
+
+	configSvc := configfile.NewService(db, storageSvc, templateStore)
+
 	// Initialize backup service
-	backupSvc := backup.NewService(db, cfg.DataDir)
+	backupSvc := backup.NewService(db, cfg.DataDir, storageSvc)
 
 	// Start scheduler
-	sched := scheduler.New(db, dockerClient, backupSvc)
+	sched := scheduler.New(db, dockerClient, backupSvc, installSvc, configSvc)
 	sched.Start()
 
 	// Create handlers
 	authHandler := api.NewAuthHandler(authSvc)
-	serverHandler := api.NewServerHandler(db, dockerClient, cfg.DataDir, templates)
-	consoleHandler := api.NewConsoleHandler(db, dockerClient)
+	serverHandler := api.NewServerHandler(db, dockerClient, cfg.DataDir, cfg.PublicHost, templateStore, healthMonitor, installSvc, configSvc)
+	consoleHandler := api.NewConsoleHandler(db, dockerClient, storageSvc)
 	statsHandler := api.NewStatsHandler(db, collector)
 	backupHandler := api.NewBackupHandler(db, backupSvc)
 	scheduleHandler := api.NewScheduleHandler(db)
+	networkHandler := api.NewNetworkHandler(db, dockerClient)
+	fileHandler := api.NewFileHandler(storageSvc)
+	execHandler := api.NewExecHandler(db, dockerClient, auditSvc)
+	shellHandler := api.NewShellHandler(db, dockerClient, auditSvc, templateStore)
+	auditHandler := api.NewAuditHandler(auditSvc)
+	installHandler := api.NewInstallHandler(dockerClient, installSvc, serverHandler)
+	templateHandler := api.NewTemplateHandler(templateStore)
+	playerHandler := api.NewPlayerHandler(playerTracker)
+	gameHandler := api.NewGameHandler(db, dockerClient, storageSvc)
+	worldHandler := api.NewWorldHandler(serverHandler, storageSvc)
+	playerListHandler := api.NewPlayerListHandler(db, dockerClient, storageSvc)
 
 	// Build router
 	r := chi.NewRouter()
@@ -96,7 +141,25 @@ func New(cfg *config.Config, db *sql.DB) (*Server, error) {
 			r.Post("/auth/logout", authHandler.Logout)
 			r.Get("/auth/me", authHandler.Me)
 
-			r.Get("/templates", serverHandler.Templates)
+			r.Route("/templates", func(r chi.Router) {
+				r.Get("/", templateHandler.List)
+				r.With(api.RequireAdmin).Post("/", templateHandler.Create)
+				r.With(api.RequireAdmin).Post("/import", templateHandler.Import)
+				r.With(api.RequireAdmin).Post("/adapter/test", templateHandler.TestAdapter)
+				r.Get("/{id}", templateHandler.Get)
+				r.With(api.RequireAdmin).Put("/{id}", templateHandler.Update)
+				r.With(api.RequireAdmin).Delete("/{id}", templateHandler.Delete)
+				r.Get("/{id}/definition", templateHandler.Definition)
+				r.Get("/{id}/versions", templateHandler.Versions)
+				r.Get("/{id}/versions/{version}", templateHandler.GetVersion)
+			})
+			r.With(api.RequireAdmin).Get("/security", serverHandler.SecurityReports)
+
+			r.Get("/networks", networkHandler.List)
+			r.With(api.RequireAdmin).Post("/networks", networkHandler.Create)
+			r.With(api.RequireAdmin).Delete("/networks/{name}", networkHandler.Delete)
+
+			r.With(api.RequireAdmin).Get("/audit", auditHandler.List)
 
 			r.Route("/servers", func(r chi.Router) {
 				r.Get("/", serverHandler.List)
@@ -108,10 +171,41 @@ func New(cfg *config.Config, db *sql.DB) (*Server, error) {
 					r.Post("/start", serverHandler.Start)
 					r.Post("/stop", serverHandler.Stop)
 					r.Post("/restart", serverHandler.Restart)
+					r.Get("/security", serverHandler.Security)
+					r.Put("/networks", serverHandler.SetNetworks)
+					r.Get("/upgrade", serverHandler.UpgradePreview)
+					r.Post("/upgrade", serverHandler.Upgrade)
+					r.Post("/command", consoleHandler.Command)
+					r.Get("/capabilities", gameHandler.Capabilities)
+					r.Post("/save", gameHandler.Save)
+					r.Post("/broadcast", gameHandler.Broadcast)
+					r.With(api.RequireAdmin).Post("/exec", execHandler.Run)
+
+					// Install
+					r.Get("/install", installHandler.Get)
+					r.Get("/install/logs", installHandler.Logs)
+					r.Post("/reinstall", installHandler.Reinstall)
 
 					// Stats
 					r.Get("/stats", statsHandler.Latest)
 					r.Get("/stats/history", statsHandler.History)
+					r.Get("/players", playerHandler.Online)
+					r.Get("/players/history", playerHandler.History)
+					r.Post("/players/{player}/kick", gameHandler.Kick)
+					r.Post("/players/{player}/ban", gameHandler.Ban)
+					r.Delete("/players/{player}/ban", gameHandler.Unban)
+					r.Get("/players/lists/{list}", playerListHandler.Get)
+					r.Post("/players/lists/{list}", playerListHandler.Add)
+					r.Delete("/players/lists/{list}/{entry}", playerListHandler.Remove)
+					// Minecraft's lists are also reachable by name
+					r.Get("/players/{list:(?:whitelist|ops|bans)}", playerListHandler.Get)
+					r.Post("/players/{list:(?:whitelist|ops|bans)}", playerListHandler.Add)
+					r.Delete("/players/{list:(?:whitelist|ops|bans)}/{entry}", playerListHandler.Remove)
+
+					// Worlds
+					r.Get("/worlds", worldHandler.List)
+					r.Post("/worlds", worldHandler.Create)
+					r.Put("/worlds/active", worldHandler.Select)
 
 					// Backups
 					r.Get("/backups", backupHandler.List)
@@ -120,6 +214,13 @@ func New(cfg *config.Config, db *sql.DB) (*Server, error) {
 					r.Delete("/backups/{backupId}", backupHandler.Delete)
 					r.Post("/backups/{backupId}/restore", backupHandler.Restore)
 
+					// Files hold generated secrets and startup scripts, so like
+					// exec and the shell they are for admins only
+					r.With(api.RequireAdmin).Get("/files", fileHandler.List)
+					r.With(api.RequireAdmin).Delete("/files", fileHandler.Delete)
+					r.With(api.RequireAdmin).Get("/files/content", fileHandler.Read)
+					r.With(api.RequireAdmin).Put("/files/content", fileHandler.Write)
+
 					// Schedules
 					r.Get("/schedules", scheduleHandler.List)
 					r.Post("/schedules", scheduleHandler.Create)
@@ -132,6 +233,8 @@ func New(cfg *config.Config, db *sql.DB) (*Server, error) {
 		// WebSocket routes (auth via query param)
 		r.Get("/servers/{id}/console", consoleHandler.Handle)
 		r.Get("/servers/{id}/stats/live", statsHandler.Live)
+		r.With(api.WebSocketAuthMiddleware(authSvc), api.RequireAdmin).Get("/servers/{id}/shell", shellHandler.Handle)
+		r.With(api.WebSocketAuthMiddleware(authSvc)).Get("/servers/{id}/install/live", installHandler.Live)
 	})
 
 	// Serve frontend static files from web/dist if it exists
@@ -149,7 +252,7 @@ func New(cfg *config.Config, db *sql.DB) (*Server, error) {
 		log.Println("Serving frontend from web/dist/")
 	}
 
-	return &Server{cfg: cfg, db: db, router: r, collector: collector, scheduler: sched}, nil
+	return &Server{cfg: cfg, db: db, router: r, collector: collector, scheduler: sched, health: healthMonitor, players: playerTracker}, nil
 }
 
 func dirExists(path string) bool {
@@ -168,6 +271,12 @@ func (s *Server) Stop() {
 	if s.scheduler != nil {
 		s.scheduler.Stop()
 	}
+	if s.health != nil {
+		s.health.Stop()
+	}
+	if s.players != nil {
+		s.players.Stop()
+	}
 }
 
 // ServeEmbeddedFrontend adds the embedded frontend static file serving.
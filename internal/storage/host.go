@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// hostVolume is a server data directory on the panel host.
+type hostVolume struct {
+	root     string
+	uid, gid int
+}
+
+// open opens the data root. Files are reached through it so symlinks that
+// a game creates can't lead outside the root.
+func (v *hostVolume) open() (*os.Root, error) {
+	return os.OpenRoot(v.root)
+}
+
+// rel returns name relative to the data root, "." for the root itself.
+func rel(name string) string {
+	if p := cleanPath(name); p != "" {
+		return filepath.FromSlash(p)
+	}
+	return "."
+}
+
+func (v *hostVolume) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	root, err := v.open()
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	f, err := root.Open(rel(name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func (v *hostVolume) WriteFile(ctx context.Context, name string, data []byte) error {
+	if cleanPath(name) == "" {
+		return ErrRoot
+	}
+	if err := os.MkdirAll(v.root, 0755); err != nil {
+		return err
+	}
+	root, err := v.open()
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	p := rel(name)
+	if err := mkdirAll(root, filepath.Dir(p)); err != nil {
+		return err
+	}
+	f, err := root.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if v.uid >= 0 {
+		f.Chown(v.uid, v.gid)
+	}
+	return f.Close()
+}
+
+// mkdirAll creates a directory and its parents inside root.
+func mkdirAll(root *os.Root, dir string) error {
+	if dir == "." {
+		return nil
+	}
+	if err := mkdirAll(root, filepath.Dir(dir)); err != nil {
+		return err
+	}
+	if err := root.Mkdir(dir, 0755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	return nil
+}
+
+func (v *hostVolume) ReadDir(ctx context.Context, name string) ([]FileInfo, error) {
+	root, err := v.open()
+	if err != nil {
+		return nil, err
+	}
+	defer root.Close()
+	dir, err := root.Open(rel(name))
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+	entries, err := dir.ReadDir(-1)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]FileInfo, 0, len(entries))
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, FileInfo{
+			Name:    e.Name(),
+			Size:    info.Size(),
+			IsDir:   e.IsDir(),
+			ModTime: info.ModTime().UTC().Format(time.RFC3339),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+func (v *hostVolume) Remove(ctx context.Context, name string) error {
+	if cleanPath(name) == "" {
+		return ErrRoot
+	}
+	root, err := v.open()
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	return removeAll(root, rel(name))
+}
+
+// removeAll removes a file or directory tree inside root. Symlinks are
+// removed, not followed.
+func removeAll(root *os.Root, name string) error {
+	info, err := root.Lstat(name)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		dir, err := root.Open(name)
+		if err != nil {
+			return err
+		}
+		entries, err := dir.ReadDir(-1)
+		dir.Close()
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := removeAll(root, filepath.Join(name, e.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return root.Remove(name)
+}
+
+func (v *hostVolume) Archive(ctx context.Context, w io.Writer) error {
+	if _, err := os.Stat(v.root); os.IsNotExist(err) {
+		return fmt.Errorf("server data directory not found: %s", v.root)
+	}
+
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	return filepath.Walk(v.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// Get path relative to the source directory
+		relPath, err := filepath.Rel(v.root, path)
+		if err != nil {
+			return err
+		}
+		if relPath == "." {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+}
+
+func (v *hostVolume) Restore(ctx context.Context, r io.Reader) error {
+	// Clear existing data
+	if err := os.RemoveAll(v.root); err != nil {
+		return fmt.Errorf("clear data directory: %w", err)
+	}
+	if err := os.MkdirAll(v.root, 0755); err != nil {
+		return fmt.Errorf("recreate data directory: %w", err)
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(v.root, header.Name)
+
+		// Prevent path traversal
+		if target != v.root && !strings.HasPrefix(target, v.root+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			f.Close()
+		}
+	}
+	// Restored files belong to the container user, like written ones
+	if v.uid >= 0 {
+		return v.Chown(ctx, v.uid, v.gid)
+	}
+	return nil
+}
+
+func (v *hostVolume) Chown(ctx context.Context, uid, gid int) error {
+	return filepath.Walk(v.root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Lchown(path, uid, gid)
+	})
+}
+
+func (v *hostVolume) Source() string {
+	return v.root
+}
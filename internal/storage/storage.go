@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+
+	"github.com/reedfamily/reedout/internal/docker"
+)
+
+// ErrRoot is returned when an operation would remove the data root itself.
+var ErrRoot = errors.New("refusing to operate on the data root")
+
+// FileInfo describes an entry in a server's data.
+type FileInfo struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	IsDir   bool   `json:"is_dir"`
+	ModTime string `json:"mod_time"`
+}
+
+// Volume gives access to a server's data, whether it lives in a host
+// directory or a Docker named volume. Paths are relative to the data root;
+// they are cleaned so they can never escape it.
+type Volume interface {
+	ReadFile(ctx context.Context, name string) ([]byte, error)
+	WriteFile(ctx context.Context, name string, data []byte) error
+	ReadDir(ctx context.Context, name string) ([]FileInfo, error)
+	Remove(ctx context.Context, name string) error
+
+	// Archive writes the whole data root to w as an uncompressed tar stream.
+	Archive(ctx context.Context, w io.Writer) error
+	// Restore replaces the data root with the contents of a tar stream.
+	Restore(ctx context.Context, r io.Reader) error
+	// Chown recursively hands the data root to uid:gid.
+	Chown(ctx context.Context, uid, gid int) error
+
+	// Source is the host path or volume name to mount into containers.
+	Source() string
+}
+
+type Service struct {
+	db      *sql.DB
+	docker  *docker.Client
+	dataDir string
+}
+
+func NewService(db *sql.DB, dockerClient *docker.Client, dataDir string) *Service {
+	return &Service{db: db, docker: dockerClient, dataDir: dataDir}
+}
+
+// ServerDataDir returns the host directory used by bind-mode servers.
+func (s *Service) ServerDataDir(serverID string) string {
+	return filepath.Join(s.dataDir, "servers", serverID)
+}
+
+// ForServer returns the Volume holding a server's data.
+func (s *Service) ForServer(serverID string) (Volume, error) {
+	var storageJSON, securityJSON string
+	err := s.db.QueryRow(`SELECT storage, security FROM servers WHERE id = ?`, serverID).Scan(&storageJSON, &securityJSON)
+	if err != nil {
+		return nil, fmt.Errorf("server not found: %w", err)
+	}
+
+	var cfg docker.StorageConfig
+	json.Unmarshal([]byte(storageJSON), &cfg)
+
+	// Files written by the panel belong to the container user when the
+	// server runs unprivileged, so the game can still modify them.
+	owner := -1
+	group := -1
+	if securityJSON != "" {
+		var sec docker.SecurityProfile
+		json.Unmarshal([]byte(securityJSON), &sec)
+		if sec.User != "" {
+			if uid, gid, err := docker.ParseUser(sec.User); err == nil {
+				owner, group = uid, gid
+			}
+		}
+	}
+
+	if cfg.UsesVolume() {
+		return &dockerVolume{docker: s.docker, name: docker.ServerVolume(serverID), uid: owner, gid: group}, nil
+	}
+	return &hostVolume{root: s.ServerDataDir(serverID), uid: owner, gid: group}, nil
+}
+
+// cleanPath turns a user-supplied path into a slash-separated path relative
+// to the data root. The root itself is "".
+func cleanPath(name string) string {
+	p := path.Clean("/" + filepath.ToSlash(name))
+	if p == "/" {
+		return ""
+	}
+	return p[1:]
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/reedfamily/reedout/internal/docker"
+)
+
+// dockerVolume is a Docker named volume, accessed through helper containers.
+type dockerVolume struct {
+	docker   *docker.Client
+	name     string
+	uid, gid int
+}
+
+func (v *dockerVolume) path(name string) string {
+	if p := cleanPath(name); p != "" {
+		return docker.HelperMount + "/" + p
+	}
+	return docker.HelperMount
+}
+
+func (v *dockerVolume) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	rc, err := v.docker.CopyFromVolume(ctx, v.name, v.path(name))
+	if err != nil {
+		if client.IsErrNotFound(err) {
+			return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+		}
+		return nil, err
+	}
+	defer rc.Close()
+
+	tr := tar.NewReader(rc)
+	header, err := tr.Next()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	if header.Typeflag != tar.TypeReg {
+		return nil, fmt.Errorf("%s is not a regular file", name)
+	}
+	return io.ReadAll(tr)
+}
+
+func (v *dockerVolume) WriteFile(ctx context.Context, name string, data []byte) error {
+	rel := cleanPath(name)
+	if rel == "" {
+		return ErrRoot
+	}
+
+	uid, gid := v.uid, v.gid
+	if uid < 0 {
+		uid, gid = 0, 0
+	}
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	err := tw.WriteHeader(&tar.Header{
+		Name:    rel,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		Uid:     uid,
+		Gid:     gid,
+		ModTime: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return v.docker.CopyToVolume(ctx, v.name, docker.HelperMount, &buf)
+}
+
+func (v *dockerVolume) ReadDir(ctx context.Context, name string) ([]FileInfo, error) {
+	// The path is passed as a positional argument so it is never parsed by the shell
+	out, err := v.docker.RunHelper(ctx, v.name, "sh", "-c",
+		`cd "$1" 2>/dev/null || exit 44; find . -mindepth 1 -maxdepth 1 -exec stat -c '%s|%Y|%F|%n' {} +`,
+		"sh", v.path(name))
+	if err != nil {
+		if strings.Contains(err.Error(), "exited with 44") {
+			return nil, fmt.Errorf("%s: %w", name, fs.ErrNotExist)
+		}
+		return nil, err
+	}
+
+	files := []FileInfo{}
+	for _, line := range strings.Split(out, "\n") {
+		parts := strings.SplitN(line, "|", 4)
+		if len(parts) != 4 {
+			continue
+		}
+		size, _ := strconv.ParseInt(parts[0], 10, 64)
+		mtime, _ := strconv.ParseInt(parts[1], 10, 64)
+		files = append(files, FileInfo{
+			Name:    strings.TrimPrefix(parts[3], "./"),
+			Size:    size,
+			IsDir:   parts[2] == "directory",
+			ModTime: time.Unix(mtime, 0).UTC().Format(time.RFC3339),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+func (v *dockerVolume) Remove(ctx context.Context, name string) error {
+	if cleanPath(name) == "" {
+		return ErrRoot
+	}
+	_, err := v.docker.RunHelper(ctx, v.name, "rm", "-rf", "--", v.path(name))
+	return err
+}
+
+func (v *dockerVolume) Archive(ctx context.Context, w io.Writer) error {
+	rc, err := v.docker.CopyFromVolume(ctx, v.name, docker.HelperMount)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// Docker roots the archive at "data/"; re-root it at the volume itself
+	// so archives look the same as those of bind-mounted servers.
+	prefix := strings.TrimPrefix(docker.HelperMount, "/") + "/"
+	tr := tar.NewReader(rc)
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := strings.TrimPrefix(header.Name, prefix)
+		if name == header.Name || name == "" {
+			continue
+		}
+		header.Name = name
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func (v *dockerVolume) Restore(ctx context.Context, r io.Reader) error {
+	if _, err := v.docker.RunHelper(ctx, v.name, "find", docker.HelperMount, "-mindepth", "1", "-delete"); err != nil {
+		return fmt.Errorf("clear volume: %w", err)
+	}
+	if err := v.docker.CopyToVolume(ctx, v.name, docker.HelperMount, r); err != nil {
+		return err
+	}
+	// Restored files belong to the container user, like written ones
+	if v.uid >= 0 {
+		return v.Chown(ctx, v.uid, v.gid)
+	}
+	return nil
+}
+
+func (v *dockerVolume) Chown(ctx context.Context, uid, gid int) error {
+	_, err := v.docker.RunHelper(ctx, v.name, "chown", "-R", fmt.Sprintf("%d:%d", uid, gid), docker.HelperMount)
+	return err
+}
+
+func (v *dockerVolume) Source() string {
+	return v.name
+}
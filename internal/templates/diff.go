@@ -0,0 +1,87 @@
+package templates
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/reedfamily/reedout/internal/docker"
+)
+
+// Change is one difference between two template versions. Path is dotted,
+// e.g. "env.TYPE" or "config_fields.version.default".
+type Change struct {
+	Path string `json:"path"`
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
+}
+
+// Diff lists the differences between two templates, sorted by path.
+func Diff(from, to *docker.GameTemplate) []Change {
+	changes := []Change{}
+	diffValues("", normalize(from), normalize(to), &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// DiffJSON lists the differences between any two JSON-encodable values.
+func DiffJSON(from, to any) []Change {
+	changes := []Change{}
+	diffValues("", toJSON(from), toJSON(to), &changes)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+func toJSON(v any) any {
+	data, _ := json.Marshal(v)
+	var out any
+	json.Unmarshal(data, &out)
+	return out
+}
+
+// normalize converts a template to generic JSON values, keying config fields
+// by their key so that reordering them isn't reported as a change.
+func normalize(t *docker.GameTemplate) map[string]any {
+	c := *t
+	c.Version = 0
+	data, _ := json.Marshal(c)
+	var m map[string]any
+	json.Unmarshal(data, &m)
+
+	if list, ok := m["config_fields"].([]any); ok {
+		fields := make(map[string]any, len(list))
+		for _, f := range list {
+			if obj, ok := f.(map[string]any); ok {
+				key, _ := obj["key"].(string)
+				fields[key] = obj
+			}
+		}
+		m["config_fields"] = fields
+	}
+	return m
+}
+
+func diffValues(path string, a, b any, changes *[]Change) {
+	ma, okA := a.(map[string]any)
+	mb, okB := b.(map[string]any)
+	if okA && okB {
+		keys := map[string]bool{}
+		for k := range ma {
+			keys[k] = true
+		}
+		for k := range mb {
+			keys[k] = true
+		}
+		for k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			diffValues(p, ma[k], mb[k], changes)
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Path: path, From: a, To: b})
+	}
+}
@@ -0,0 +1,118 @@
+package templates
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/reedfamily/reedout/internal/docker"
+)
+
+// Merge resolves a template that extends base. Settings the child leaves
+// empty are inherited. Env, volumes and images are merged key by key, and
+// config fields are merged by key: a child field only needs the attributes
+// it changes, and a field with "remove" drops the inherited one. Ports and
+// the security, health check, install and adapter sections are replaced as a
+// whole.
+func Merge(base, child docker.GameTemplate) (docker.GameTemplate, error) {
+	t := base
+	t.ID = child.ID
+	t.Version = 0
+	t.Extends = child.Extends
+
+	override(&t.Name, child.Name)
+	override(&t.Game, child.Game)
+	override(&t.Description, child.Description)
+	override(&t.Image, child.Image)
+	override(&t.Memory, child.Memory)
+	override(&t.Shell, child.Shell)
+	override(&t.Startup, child.Startup)
+	if child.CPU != 0 {
+		t.CPU = child.CPU
+	}
+	if len(child.Ports) > 0 {
+		t.Ports = child.Ports
+	}
+	if child.Security != nil {
+		t.Security = child.Security
+	}
+	if child.Storage.Mode != "" {
+		t.Storage = child.Storage
+	}
+	if child.HealthCheck != nil {
+		t.HealthCheck = child.HealthCheck
+	}
+	if child.Install != nil {
+		t.Install = child.Install
+	}
+	if child.Adapter != nil {
+		t.Adapter = child.Adapter
+	}
+
+	t.Env = mergeMap(base.Env, child.Env)
+	t.Volumes = mergeMap(base.Volumes, child.Volumes)
+	t.Images = mergeMap(base.Images, child.Images)
+
+	fields := make([]docker.ConfigField, len(base.ConfigFields))
+	copy(fields, base.ConfigFields)
+	for _, cf := range child.ConfigFields {
+		i := -1
+		for j, f := range fields {
+			if f.Key == cf.Key {
+				i = j
+				break
+			}
+		}
+		switch {
+		case cf.Remove && i < 0:
+			return t, fmt.Errorf("config field %s: no inherited field to remove", cf.Key)
+		case cf.Remove:
+			fields = append(fields[:i], fields[i+1:]...)
+		case i < 0:
+			fields = append(fields, cf)
+		default:
+			fields[i] = mergeField(fields[i], cf)
+		}
+	}
+	t.ConfigFields = fields
+	return t, nil
+}
+
+func mergeField(base, child docker.ConfigField) docker.ConfigField {
+	f := base
+	override(&f.Label, child.Label)
+	override(&f.Type, child.Type)
+	override(&f.Default, child.Default)
+	override(&f.Description, child.Description)
+	override(&f.EnvVar, child.EnvVar)
+	override(&f.Pattern, child.Pattern)
+	override(&f.File, child.File)
+	override(&f.FileKey, child.FileKey)
+	override(&f.FileFormat, child.FileFormat)
+	if child.Options != nil {
+		f.Options = child.Options
+	}
+	if child.Min != nil {
+		f.Min = child.Min
+	}
+	if child.Max != nil {
+		f.Max = child.Max
+	}
+	f.Required = f.Required || child.Required
+	return f
+}
+
+func override(dst *string, v string) {
+	if v != "" {
+		*dst = v
+	}
+}
+
+func mergeMap(base, child map[string]string) map[string]string {
+	if base == nil && child == nil {
+		return nil
+	}
+	m := make(map[string]string, len(base)+len(child))
+	maps.Copy(m, base)
+	maps.Copy(m, child)
+	return m
+}
@@ -0,0 +1,200 @@
+package templates
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/reedfamily/reedout/internal/docker"
+)
+
+// Vars are the values template placeholders expand to for one server:
+//
+//	{server_id}    the server's ID
+//	{server_name}  the server's name
+//	{data_dir}     the host directory or volume holding the server's data
+//	{port:NAME}    the host port of a port by name or index, or the
+//	               container port of an internal port; the first port of
+//	               a range
+//	{random:N}     N random letters and digits, generated once per server
+//	{uuid}         a random UUID, generated once per server
+//
+// Unknown placeholders are left as they are, so shell variables such as
+// ${name} in scripts pass through untouched.
+type Vars struct {
+	ServerID   string
+	ServerName string
+	DataDir    string
+	Ports      map[string]string // port name -> host port
+
+	// Generated holds generated values keyed by where they appear, e.g.
+	// "env.RCON_PASSWORD". It is persisted with the server so the values
+	// survive container recreation and template upgrades.
+	Generated map[string]string
+}
+
+var placeholder = regexp.MustCompile(`\{([a-z_]+)(?::([a-z0-9_]+))?\}`)
+
+// maxRandom caps the length of {random:N} values.
+const maxRandom = 256
+
+// Expand replaces the placeholders in s. location names where s comes from
+// and keys any values generated for it.
+func (v *Vars) Expand(location, s string) (string, error) {
+	var err error
+	generated := 0
+	out := placeholder.ReplaceAllStringFunc(s, func(m string) string {
+		sub := placeholder.FindStringSubmatch(m)
+		name, arg := sub[1], sub[2]
+		switch name {
+		case "server_id":
+			return v.ServerID
+		case "server_name":
+			return v.ServerName
+		case "data_dir":
+			return v.DataDir
+		case "port":
+			p, ok := v.Ports[arg]
+			if !ok {
+				err = fmt.Errorf("%s: unknown port %q", location, arg)
+				return m
+			}
+			return p
+		case "random", "uuid":
+			// Each generator in a string gets its own value
+			generated++
+			key := location
+			if generated > 1 {
+				key = fmt.Sprintf("%s#%d", location, generated)
+			}
+			if val, ok := v.Generated[key]; ok {
+				return val
+			}
+			val, genErr := generate(name, arg)
+			if genErr != nil {
+				err = fmt.Errorf("%s: %w", location, genErr)
+				return m
+			}
+			if v.Generated == nil {
+				v.Generated = make(map[string]string)
+			}
+			v.Generated[key] = val
+			return val
+		}
+		return m
+	})
+	return out, err
+}
+
+// ExpandMap expands every value of m in place.
+func (v *Vars) ExpandMap(prefix string, m map[string]string) error {
+	for k, val := range m {
+		expanded, err := v.Expand(prefix+"."+k, val)
+		if err != nil {
+			return err
+		}
+		m[k] = expanded
+	}
+	return nil
+}
+
+// Template returns a copy of t with placeholders expanded in its ports,
+// volumes, startup command, health check command and install step. Env is
+// left alone; expand it with ExpandMap once config values are merged in.
+// Ports are named with docker.NamePorts before their names are added to v.
+func (v *Vars) Template(t docker.GameTemplate) (docker.GameTemplate, error) {
+	var err error
+	ports := make([]docker.PortMapping, len(t.Ports))
+	for i, p := range t.Ports {
+		if p.Host, err = v.Expand("ports."+strconv.Itoa(i), p.Host); err != nil {
+			return t, err
+		}
+		if p.Container, err = v.Expand("ports."+strconv.Itoa(i)+".container", p.Container); err != nil {
+			return t, err
+		}
+		ports[i] = p
+	}
+	docker.NamePorts(ports)
+	t.Ports = ports
+	if v.Ports == nil {
+		v.Ports = make(map[string]string)
+	}
+	for i, p := range ports {
+		port := docker.FirstPort(p.Host)
+		if p.Internal {
+			port = docker.FirstPort(p.Container)
+		}
+		v.Ports[p.Name] = port
+		v.Ports[strconv.Itoa(i)] = port
+	}
+
+	volumes := make(map[string]string, len(t.Volumes))
+	for host, container := range t.Volumes {
+		h, err := v.Expand("volumes."+host, host)
+		if err != nil {
+			return t, err
+		}
+		c, err := v.Expand("volumes."+host+".target", container)
+		if err != nil {
+			return t, err
+		}
+		volumes[h] = c
+	}
+	t.Volumes = volumes
+
+	if t.Startup, err = v.Expand("startup", t.Startup); err != nil {
+		return t, err
+	}
+
+	if t.HealthCheck != nil {
+		hc := *t.HealthCheck
+		hc.Command = make([]string, len(t.HealthCheck.Command))
+		for i, arg := range t.HealthCheck.Command {
+			if hc.Command[i], err = v.Expand("health_check.command."+strconv.Itoa(i), arg); err != nil {
+				return t, err
+			}
+		}
+		t.HealthCheck = &hc
+	}
+
+	if t.Install != nil {
+		inst := *t.Install
+		if inst.Script, err = v.Expand("install.script", inst.Script); err != nil {
+			return t, err
+		}
+		inst.Env = make(map[string]string, len(t.Install.Env))
+		for k, val := range t.Install.Env {
+			inst.Env[k] = val
+		}
+		if err := v.ExpandMap("install.env", inst.Env); err != nil {
+			return t, err
+		}
+		t.Install = &inst
+	}
+	return t, nil
+}
+
+const randomChars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+func generate(name, arg string) (string, error) {
+	if name == "uuid" {
+		return uuid.New().String(), nil
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > maxRandom {
+		return "", fmt.Errorf("{random:N} needs a length between 1 and %d", maxRandom)
+	}
+	b := make([]byte, n)
+	max := big.NewInt(int64(len(randomChars)))
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = randomChars[idx.Int64()]
+	}
+	return string(b), nil
+}
@@ -0,0 +1,579 @@
+// Package templates stores game templates in the database with a version
+// history. The template directory is only used as a seed source.
+package templates
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/reedfamily/reedout/internal/docker"
+	"github.com/reedfamily/reedout/internal/game"
+	"github.com/reedfamily/reedout/internal/game/pattern"
+)
+
+var (
+	ErrNotFound = errors.New("template not found")
+	ErrExists   = errors.New("template already exists")
+	ErrInUse    = errors.New("template is used by servers")
+	ErrExtended = errors.New("template is extended by other templates")
+	ErrInvalid  = errors.New("invalid template")
+)
+
+var validID = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Template sources. File templates are updated from the template directory
+// on startup until they are edited through the API.
+const (
+	SourceFile = "file"
+	SourceAPI  = "api"
+)
+
+// Version describes one saved revision of a template.
+type Version struct {
+	Version   int    `json:"version"`
+	CreatedAt string `json:"created_at"`
+}
+
+type Store struct {
+	db  *sql.DB
+	dir string
+}
+
+func NewStore(db *sql.DB, dir string) *Store {
+	return &Store{db: db, dir: dir}
+}
+
+// Seed loads the template directory into the store. New files are added and
+// changed files get a new version, unless the template has been edited or
+// deleted through the API since. Templates are seeded after the templates
+// they extend.
+func (s *Store) Seed() error {
+	files, err := docker.LoadTemplates(s.dir)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]docker.GameTemplate, len(files))
+	for _, t := range files {
+		byID[t.ID] = t
+	}
+	seeded := make(map[string]bool, len(files))
+	var seed func(t docker.GameTemplate) error
+	seed = func(t docker.GameTemplate) error {
+		if seeded[t.ID] {
+			return nil
+		}
+		seeded[t.ID] = true
+		if parent, ok := byID[t.Extends]; ok {
+			if err := seed(parent); err != nil {
+				return err
+			}
+		}
+		return s.seedOne(t)
+	}
+	for _, t := range files {
+		if err := seed(t); err != nil {
+			return err
+		}
+	}
+
+	// Servers created before templates were versioned predate any later
+	// edits, so they are on the first stored version
+	_, err = s.db.Exec(`UPDATE servers SET template_version = (SELECT MIN(version) FROM template_versions WHERE template_id = servers.template_id)
+		WHERE template_version = 0 AND template_id IN (SELECT template_id FROM template_versions)`)
+	if err != nil {
+		return err
+	}
+	return s.declare()
+}
+
+func (s *Store) seedOne(t docker.GameTemplate) error {
+	var source string
+	var version int
+	err := s.db.QueryRow(`SELECT source, version FROM templates WHERE id = ?`, t.ID).Scan(&source, &version)
+	switch {
+	case err == sql.ErrNoRows:
+		// A template with history but no row was deleted; don't bring it back
+		var versions int
+		if err := s.db.QueryRow(`SELECT COUNT(*) FROM template_versions WHERE template_id = ?`, t.ID).Scan(&versions); err != nil {
+			return err
+		}
+		if versions > 0 {
+			return nil
+		}
+		if _, err := s.save(t, SourceFile, false); err != nil {
+			return fmt.Errorf("seed %s: %w", t.ID, err)
+		}
+		log.Printf("templates: added %s from template directory", t.ID)
+	case err != nil:
+		return err
+	case source == SourceFile:
+		latest, err := s.definition(t.ID, version)
+		if err != nil {
+			return err
+		}
+		if same(latest, &t) {
+			return nil
+		}
+		if _, err := s.save(t, SourceFile, true); err != nil {
+			return fmt.Errorf("seed %s: %w", t.ID, err)
+		}
+		log.Printf("templates: updated %s from template directory", t.ID)
+	}
+	return nil
+}
+
+// List returns the latest version of every template.
+func (s *Store) List() ([]docker.GameTemplate, error) {
+	rows, err := s.db.Query(`SELECT t.version, v.data FROM templates t
+		JOIN template_versions v ON v.template_id = t.id AND v.version = t.version
+		ORDER BY t.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []docker.GameTemplate{}
+	for rows.Next() {
+		var version int
+		var data string
+		if err := rows.Scan(&version, &data); err != nil {
+			return nil, err
+		}
+		var t docker.GameTemplate
+		if err := json.Unmarshal([]byte(data), &t); err != nil {
+			return nil, err
+		}
+		t.Version = version
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// Get returns the latest version of a template.
+func (s *Store) Get(id string) (*docker.GameTemplate, error) {
+	var version int
+	err := s.db.QueryRow(`SELECT version FROM templates WHERE id = ?`, id).Scan(&version)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.GetVersion(id, version)
+}
+
+// GetVersion returns a specific version of a template. Old versions remain
+// available after the template is deleted.
+func (s *Store) GetVersion(id string, version int) (*docker.GameTemplate, error) {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM template_versions WHERE template_id = ? AND version = ?`, id, version).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var t docker.GameTemplate
+	if err := json.Unmarshal([]byte(data), &t); err != nil {
+		return nil, fmt.Errorf("parse template %s v%d: %w", id, version, err)
+	}
+	t.Version = version
+	return &t, nil
+}
+
+// Definition returns the latest version of a template as it was saved,
+// before the template it extends is merged in.
+func (s *Store) Definition(id string) (*docker.GameTemplate, error) {
+	var version int
+	err := s.db.QueryRow(`SELECT version FROM templates WHERE id = ?`, id).Scan(&version)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.definition(id, version)
+}
+
+func (s *Store) definition(id string, version int) (*docker.GameTemplate, error) {
+	var data, definition string
+	err := s.db.QueryRow(`SELECT data, definition FROM template_versions WHERE template_id = ? AND version = ?`, id, version).
+		Scan(&data, &definition)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Templates that don't extend another are stored as they were saved
+	if definition == "" {
+		definition = data
+	}
+	var t docker.GameTemplate
+	if err := json.Unmarshal([]byte(definition), &t); err != nil {
+		return nil, fmt.Errorf("parse template %s v%d: %w", id, version, err)
+	}
+	t.Version = version
+	return &t, nil
+}
+
+// Versions lists the saved versions of a template, newest first.
+func (s *Store) Versions(id string) ([]Version, error) {
+	rows, err := s.db.Query(`SELECT version, created_at FROM template_versions WHERE template_id = ? ORDER BY version DESC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := []Version{}
+	for rows.Next() {
+		var v Version
+		if err := rows.Scan(&v.Version, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	if len(versions) == 0 {
+		return nil, ErrNotFound
+	}
+	return versions, rows.Err()
+}
+
+// Create adds a new template as version 1.
+func (s *Store) Create(t docker.GameTemplate) (*docker.GameTemplate, error) {
+	if err := s.prepare(&t); err != nil {
+		return nil, err
+	}
+	saved, err := s.save(t, SourceAPI, false)
+	if err != nil {
+		return nil, err
+	}
+	return saved, s.declare()
+}
+
+// Update saves a new version of an existing template. Saving an unchanged
+// template returns the current version. Templates extending it get a new
+// version as well.
+func (s *Store) Update(id string, t docker.GameTemplate) (*docker.GameTemplate, error) {
+	t.ID = id
+	if err := s.prepare(&t); err != nil {
+		return nil, err
+	}
+	latest, err := s.Definition(id)
+	if err != nil {
+		return nil, err
+	}
+	if same(latest, &t) {
+		return s.GetVersion(id, latest.Version)
+	}
+	saved, err := s.save(t, SourceAPI, true)
+	if err != nil {
+		return nil, err
+	}
+	return saved, s.declare()
+}
+
+// Delete removes a template that no server uses and no template extends.
+// Its versions are kept.
+func (s *Store) Delete(id string) error {
+	var inUse int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM servers WHERE template_id = ?`, id).Scan(&inUse); err != nil {
+		return err
+	}
+	if inUse > 0 {
+		return ErrInUse
+	}
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM templates WHERE extends = ?`, id).Scan(&inUse); err != nil {
+		return err
+	}
+	if inUse > 0 {
+		return ErrExtended
+	}
+	result, err := s.db.Exec(`DELETE FROM templates WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return s.declare()
+}
+
+// prepare checks the ID and resolves relative paths of a template submitted
+// through the API. It is validated when saved.
+func (s *Store) prepare(t *docker.GameTemplate) error {
+	if !validID.MatchString(t.ID) {
+		return fmt.Errorf("%w: id must be lowercase letters, digits, '_' or '-'", ErrInvalid)
+	}
+	if t.Security != nil && t.Security.Seccomp != "" && !filepath.IsAbs(t.Security.Seccomp) {
+		t.Security.Seccomp = filepath.Join(s.dir, t.Security.Seccomp)
+	}
+	return nil
+}
+
+// resolve merges a template definition with the latest version of the
+// template it extends and validates the result.
+func (s *Store) resolve(def docker.GameTemplate) (docker.GameTemplate, error) {
+	t := def
+	if def.Extends != "" {
+		// Walk up the chain so a template can't end up extending itself
+		for id := def.Extends; id != ""; {
+			if id == def.ID {
+				return t, fmt.Errorf("%w: extends %s, which extends %s", ErrInvalid, def.Extends, def.ID)
+			}
+			err := s.db.QueryRow(`SELECT extends FROM templates WHERE id = ?`, id).Scan(&id)
+			if err == sql.ErrNoRows {
+				return t, fmt.Errorf("%w: extends unknown template %s", ErrInvalid, def.Extends)
+			}
+			if err != nil {
+				return t, err
+			}
+		}
+		base, err := s.Get(def.Extends)
+		if err != nil {
+			return t, err
+		}
+		if t, err = Merge(*base, def); err != nil {
+			return t, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+	}
+	if err := t.Validate(); err != nil {
+		return t, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return t, nil
+}
+
+// children returns the latest definitions of the templates extending id.
+func (s *Store) children(id string) ([]docker.GameTemplate, []string, error) {
+	rows, err := s.db.Query(`SELECT id, version, source FROM templates WHERE extends = ? ORDER BY id`, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	type child struct {
+		id, source string
+		version    int
+	}
+	var list []child
+	for rows.Next() {
+		var c child
+		if err := rows.Scan(&c.id, &c.version, &c.source); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		list = append(list, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	defs := make([]docker.GameTemplate, 0, len(list))
+	sources := make([]string, 0, len(list))
+	for _, c := range list {
+		def, err := s.definition(c.id, c.version)
+		if err != nil {
+			return nil, nil, err
+		}
+		defs = append(defs, *def)
+		sources = append(sources, c.source)
+	}
+	return defs, sources, nil
+}
+
+// save writes a template as a new version. With update unset the template
+// must not exist yet. Templates extending it are re-resolved and saved too.
+func (s *Store) save(def docker.GameTemplate, source string, update bool) (*docker.GameTemplate, error) {
+	def.Version = 0
+	t, err := s.resolve(def)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAdapter(t); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	var definition []byte
+	if def.Extends != "" {
+		if definition, err = json.Marshal(def); err != nil {
+			return nil, err
+		}
+	}
+
+	// Refuse changes that would leave a template extending this one invalid
+	children, sources, err := s.children(t.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range children {
+		merged, err := Merge(t, c)
+		if err == nil {
+			err = merged.Validate()
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: breaks %s, which extends it: %v", ErrInvalid, c.ID, err)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var version int
+	err = tx.QueryRow(`SELECT version FROM templates WHERE id = ?`, t.ID).Scan(&version)
+	switch {
+	case err == sql.ErrNoRows && update:
+		return nil, ErrNotFound
+	case err == nil && !update:
+		return nil, ErrExists
+	case err != nil && err != sql.ErrNoRows:
+		return nil, err
+	}
+
+	// Versions outlive deleted templates, so a re-created ID continues the history
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(version), 0) + 1 FROM template_versions WHERE template_id = ?`, t.ID).Scan(&version); err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`INSERT INTO template_versions (template_id, version, data, definition) VALUES (?, ?, ?, ?)`,
+		t.ID, version, string(data), string(definition))
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(`INSERT INTO templates (id, version, source, extends) VALUES (?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET version = excluded.version, source = excluded.source,
+			extends = excluded.extends, updated_at = ?`,
+		t.ID, version, source, def.Extends, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	for i, c := range children {
+		latest, err := s.GetVersion(c.ID, c.Version)
+		if err != nil {
+			return nil, err
+		}
+		if merged, err := s.resolve(c); err == nil && same(latest, &merged) {
+			continue
+		}
+		if _, err := s.save(c, sources[i], true); err != nil {
+			return nil, fmt.Errorf("update %s: %w", c.ID, err)
+		}
+	}
+
+	t.Version = version
+	return &t, nil
+}
+
+// checkAdapter refuses a declared adapter for a game that has a built-in
+// one, or that differs from the adapter another template of the same game
+// declares. Templates extending t are re-resolved along with it, so they
+// don't count.
+func (s *Store) checkAdapter(t docker.GameTemplate) error {
+	if t.Adapter == nil {
+		return nil
+	}
+	if game.Builtin(t.Game) {
+		return fmt.Errorf("%w: game %s has a built-in adapter", ErrInvalid, t.Game)
+	}
+	list, err := s.List()
+	if err != nil {
+		return err
+	}
+	want, _ := json.Marshal(t.Adapter)
+	for _, other := range list {
+		if other.ID == t.ID || other.Game != t.Game || other.Adapter == nil {
+			continue
+		}
+		if got, _ := json.Marshal(other.Adapter); bytes.Equal(got, want) {
+			continue
+		}
+		below, err := s.extendsFrom(other.ID, t.ID)
+		if err != nil {
+			return err
+		}
+		if !below {
+			return fmt.Errorf("%w: template %s declares a different adapter for game %s", ErrInvalid, other.ID, t.Game)
+		}
+	}
+	return nil
+}
+
+// extendsFrom reports whether template id extends ancestor, directly or not.
+func (s *Store) extendsFrom(id, ancestor string) (bool, error) {
+	for seen := map[string]bool{}; id != "" && !seen[id]; {
+		seen[id] = true
+		err := s.db.QueryRow(`SELECT extends FROM templates WHERE id = ?`, id).Scan(&id)
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if id == ancestor {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// declare registers the adapters templates declare. Games with a built-in
+// adapter keep it, and of several templates declaring one for the same game
+// the first by ID wins. Saving refuses both cases, so they only come from
+// templates stored before a built-in adapter was added.
+func (s *Store) declare() error {
+	list, err := s.List()
+	if err != nil {
+		return err
+	}
+	var adapters []game.GameAdapter
+	declaredBy := make(map[string]docker.GameTemplate)
+	for _, t := range list {
+		if t.Adapter == nil || t.Game == "" {
+			continue
+		}
+		if game.Builtin(t.Game) {
+			log.Printf("Warning: template %s declares an adapter for %s, which has a built-in one; ignoring it", t.ID, t.Game)
+			continue
+		}
+		if first, ok := declaredBy[t.Game]; ok {
+			a, _ := json.Marshal(first.Adapter)
+			b, _ := json.Marshal(t.Adapter)
+			if !bytes.Equal(a, b) {
+				log.Printf("Warning: template %s declares a different adapter for %s than %s; ignoring it", t.ID, t.Game, first.ID)
+			}
+			continue
+		}
+		a, err := pattern.New(t.Game, *t.Adapter)
+		if err != nil {
+			log.Printf("templates: adapter of %s: %v", t.ID, err)
+			continue
+		}
+		declaredBy[t.Game] = t
+		adapters = append(adapters, a)
+	}
+	game.SetDeclared(adapters)
+	return nil
+}
+
+// same reports whether two templates have identical content, ignoring version.
+func same(a, b *docker.GameTemplate) bool {
+	x, y := *a, *b
+	x.Version, y.Version = 0, 0
+	dx, _ := json.Marshal(x)
+	dy, _ := json.Marshal(y)
+	return bytes.Equal(dx, dy)
+}
@@ -17,7 +17,10 @@ function statusBadgeVariant(status: string) {
 }
 
 const gameLabels: Record<string, string> = {
+  bedrock: "Minecraft Bedrock",
+  factorio: "Factorio",
   minecraft: "Minecraft",
+  source: "Source",
   vintagestory: "Vintage Story",
   valheim: "Valheim",
   terraria: "Terraria",
@@ -49,9 +52,16 @@ export function ServerCard({ server }: { server: Server }) {
       </CardHeader>
       <CardContent>
         <div className="text-xs text-muted-foreground mb-3">
-          {server.ports.length > 0 && (
+          {server.address ? (
+            <span className="font-mono">{server.address}</span>
+          ) : server.ports.length > 0 && (
             <span>Port {server.ports[0].host}</span>
           )}
+          {server.game_status && !server.game_status.error && (
+            <span className="ml-3">
+              {server.game_status.online}/{server.game_status.max} players
+            </span>
+          )}
         </div>
         <div className="flex gap-2">
           {!isRunning ? (
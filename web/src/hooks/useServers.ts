@@ -48,6 +48,20 @@ export function useServerAction() {
   });
 }
 
+export function useCapabilities(id: string) {
+  return useQuery({
+    queryKey: ["servers", id, "capabilities"],
+    queryFn: () => api.getCapabilities(id),
+    staleTime: Infinity,
+  });
+}
+
+export function useSaveWorld() {
+  return useMutation({
+    mutationFn: (id: string) => api.saveWorld(id),
+  });
+}
+
 export function useTemplates() {
   return useQuery({
     queryKey: ["templates"],
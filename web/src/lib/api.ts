@@ -1,7 +1,15 @@
-import type { Server, GameTemplate, CreateServerRequest, ServerStats, ServerBackup, ServerSchedule, CreateScheduleRequest } from "@/types/server";
+import type { Server, GameTemplate, CreateServerRequest, ServerStats, ServerBackup, ServerSchedule, CreateScheduleRequest, OnlinePlayers, PlayerSession, GameCapabilities, AdapterSpec, AdapterTestResult, World, ListEntry } from "@/types/server";
 
 const BASE = "/api/v1";
 
+// Minecraft's whitelist, ops and bans have their own routes; other games'
+// lists live under /players/lists.
+const minecraftLists = ["whitelist", "ops", "bans"];
+
+function playerListPath(id: string, list: string) {
+  return minecraftLists.includes(list) ? `/servers/${id}/players/${list}` : `/servers/${id}/players/lists/${list}`;
+}
+
 class ApiError extends Error {
   constructor(public status: number, message: string) {
     super(message);
@@ -41,7 +49,7 @@ export const api = {
 
   logout: () => request("/auth/logout", { method: "POST" }),
 
-  me: () => request<{ id: number; username: string }>("/auth/me"),
+  me: () => request<{ id: number; username: string; admin: boolean }>("/auth/me"),
 
   // Servers
   listServers: () => request<Server[]>("/servers"),
@@ -54,7 +62,7 @@ export const api = {
       body: JSON.stringify(data),
     }),
 
-  updateServer: (id: string, data: { name: string }) =>
+  updateServer: (id: string, data: { name?: string; env?: Record<string, string> }) =>
     request<Server>(`/servers/${id}`, {
       method: "PUT",
       body: JSON.stringify(data),
@@ -75,12 +83,89 @@ export const api = {
   // Templates
   listTemplates: () => request<GameTemplate[]>("/templates"),
 
+  testAdapter: (lines: string[], source: { adapter: AdapterSpec } | { template: string }) =>
+    request<AdapterTestResult[]>("/templates/adapter/test", {
+      method: "POST",
+      body: JSON.stringify({ ...source, lines }),
+    }),
+
   // Stats
   getStats: (id: string) => request<ServerStats>(`/servers/${id}/stats`),
 
   getStatsHistory: (id: string, period = "1h") =>
     request<ServerStats[]>(`/servers/${id}/stats/history?period=${period}`),
 
+  // Console
+  sendCommand: (id: string, command: string) =>
+    request<{ output: string; via: "rcon" | "stdin" }>(`/servers/${id}/command`, {
+      method: "POST",
+      body: JSON.stringify({ command }),
+    }),
+
+  // Game features
+  getCapabilities: (id: string) =>
+    request<GameCapabilities>(`/servers/${id}/capabilities`),
+
+  saveWorld: (id: string) =>
+    request(`/servers/${id}/save`, { method: "POST" }),
+
+  broadcast: (id: string, message: string) =>
+    request(`/servers/${id}/broadcast`, {
+      method: "POST",
+      body: JSON.stringify({ message }),
+    }),
+
+  // Worlds
+  listWorlds: (id: string) => request<World[]>(`/servers/${id}/worlds`),
+
+  selectWorld: (id: string, name: string) =>
+    request<World[]>(`/servers/${id}/worlds/active`, {
+      method: "PUT",
+      body: JSON.stringify({ name }),
+    }),
+
+  createWorld: (id: string, name: string, settings?: Record<string, unknown>) =>
+    request<World[]>(`/servers/${id}/worlds`, {
+      method: "POST",
+      body: JSON.stringify({ name, settings }),
+    }),
+
+  // Player lists
+  getPlayerList: (id: string, list: string) =>
+    request<ListEntry[]>(playerListPath(id, list)),
+
+  addToPlayerList: (id: string, list: string, entry: ListEntry) =>
+    request<ListEntry[]>(playerListPath(id, list), {
+      method: "POST",
+      body: JSON.stringify(entry),
+    }),
+
+  removeFromPlayerList: (id: string, list: string, player: string) =>
+    request<ListEntry[]>(`${playerListPath(id, list)}/${encodeURIComponent(player)}`, {
+      method: "DELETE",
+    }),
+
+  // Players
+  getPlayers: (id: string) => request<OnlinePlayers>(`/servers/${id}/players`),
+
+  getPlayerHistory: (id: string, limit = 100) =>
+    request<PlayerSession[]>(`/servers/${id}/players/history?limit=${limit}`),
+
+  kickPlayer: (id: string, player: string, reason = "") =>
+    request(`/servers/${id}/players/${encodeURIComponent(player)}/kick`, {
+      method: "POST",
+      body: JSON.stringify({ reason }),
+    }),
+
+  banPlayer: (id: string, player: string, reason = "") =>
+    request(`/servers/${id}/players/${encodeURIComponent(player)}/ban`, {
+      method: "POST",
+      body: JSON.stringify({ reason }),
+    }),
+
+  unbanPlayer: (id: string, player: string) =>
+    request(`/servers/${id}/players/${encodeURIComponent(player)}/ban`, { method: "DELETE" }),
+
   // Backups
   listBackups: (id: string) => request<ServerBackup[]>(`/servers/${id}/backups`),
 
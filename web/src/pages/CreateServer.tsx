@@ -22,7 +22,7 @@ export function CreateServer() {
     if (t) {
       const defaults: Record<string, string> = {};
       for (const f of t.config_fields) {
-        defaults[f.env_var] = f.default;
+        defaults[f.env_var || f.key] = f.default;
       }
       setEnv(defaults);
       if (!name) setName(`My ${t.name} Server`);
@@ -113,8 +113,8 @@ export function CreateServer() {
                   <p className="text-xs text-muted-foreground">{field.description}</p>
                   {field.type === "select" && field.options ? (
                     <Select
-                      value={env[field.env_var] ?? field.default}
-                      onChange={(e) => setEnv({ ...env, [field.env_var]: e.target.value })}
+                      value={env[field.env_var || field.key] ?? field.default}
+                      onChange={(e) => setEnv({ ...env, [field.env_var || field.key]: e.target.value })}
                     >
                       {field.options.map((opt) => (
                         <option key={opt} value={opt}>{opt}</option>
@@ -123,8 +123,8 @@ export function CreateServer() {
                   ) : (
                     <Input
                       type={field.type === "number" ? "number" : "text"}
-                      value={env[field.env_var] ?? field.default}
-                      onChange={(e) => setEnv({ ...env, [field.env_var]: e.target.value })}
+                      value={env[field.env_var || field.key] ?? field.default}
+                      onChange={(e) => setEnv({ ...env, [field.env_var || field.key]: e.target.value })}
                     />
                   )}
                 </div>
@@ -1,10 +1,10 @@
 import { useState } from "react";
 import { useParams, useNavigate } from "react-router-dom";
-import { ArrowLeft, Play, Square, RotateCw, Trash2 } from "lucide-react";
+import { ArrowLeft, Play, Square, RotateCw, Save, Trash2 } from "lucide-react";
 import { Button } from "@/components/ui/button";
 import { Badge } from "@/components/ui/badge";
 import { Card, CardHeader, CardTitle, CardContent } from "@/components/ui/card";
-import { useServer, useServerAction, useDeleteServer } from "@/hooks/useServers";
+import { useServer, useServerAction, useDeleteServer, useCapabilities, useSaveWorld } from "@/hooks/useServers";
 import { ConsoleTerminal } from "@/components/ConsoleTerminal";
 import { StatsCharts } from "@/components/StatsCharts";
 import { BackupList } from "@/components/BackupList";
@@ -12,7 +12,10 @@ import { ScheduleList } from "@/components/ScheduleList";
 import { formatBytes, cn } from "@/lib/utils";
 
 const gameLabels: Record<string, string> = {
+  bedrock: "Minecraft Bedrock",
+  factorio: "Factorio",
   minecraft: "Minecraft",
+  source: "Source",
   vintagestory: "Vintage Story",
   valheim: "Valheim",
   terraria: "Terraria",
@@ -35,6 +38,8 @@ export function ServerDetail() {
   const { data: server, isLoading, error } = useServer(id!);
   const action = useServerAction();
   const deleteServer = useDeleteServer();
+  const { data: capabilities } = useCapabilities(id!);
+  const saveWorld = useSaveWorld();
   const [activeTab, setActiveTab] = useState<Tab>("overview");
 
   if (isLoading) {
@@ -59,7 +64,7 @@ export function ServerDetail() {
   }
 
   const isRunning = server.status === "running";
-  const isBusy = action.isPending || deleteServer.isPending;
+  const isBusy = action.isPending || deleteServer.isPending || saveWorld.isPending;
 
   const tabs: { key: Tab; label: string; runningOnly?: boolean }[] = [
     { key: "overview", label: "Overview" },
@@ -97,6 +102,11 @@ export function ServerDetail() {
               <Button variant="secondary" onClick={() => action.mutate({ id: server.id, action: "restart" })} disabled={isBusy}>
                 <RotateCw className="h-4 w-4" /> Restart
               </Button>
+              {capabilities?.save && (
+                <Button variant="secondary" onClick={() => saveWorld.mutate(server.id)} disabled={isBusy}>
+                  <Save className="h-4 w-4" /> Save
+                </Button>
+              )}
             </>
           )}
           <Button
@@ -144,10 +154,18 @@ export function ServerDetail() {
               <CardTitle className="text-base">Connection</CardTitle>
             </CardHeader>
             <CardContent className="space-y-2 text-sm">
+              {server.address && (
+                <div className="flex justify-between">
+                  <span className="text-muted-foreground">Join address</span>
+                  <span className="font-mono">{server.address}</span>
+                </div>
+              )}
               {server.ports.map((p, i) => (
                 <div key={i} className="flex justify-between">
-                  <span className="text-muted-foreground">Port ({p.protocol})</span>
-                  <span className="font-mono">{p.host}:{p.container}</span>
+                  <span className="text-muted-foreground">
+                    {p.name ?? "Port"} ({p.protocol}{p.internal ? ", internal" : ""})
+                  </span>
+                  <span className="font-mono">{p.internal ? p.container : `${p.host}:${p.container}`}</span>
                 </div>
               ))}
               <div className="flex justify-between">
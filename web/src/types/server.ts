@@ -1,25 +1,84 @@
 export interface PortMapping {
+  name?: string;
+  role?: "game" | "query" | "rcon" | "web";
   host: string;
   container: string;
   protocol: string;
+  internal?: boolean;
 }
 
 export interface Server {
   id: string;
   name: string;
   game: string;
+  template_id: string;
+  template_version: number;
   container_id: string;
   image: string;
   ports: PortMapping[];
   env: Record<string, string>;
+  config: Record<string, string>;
   volumes: Record<string, string>;
   memory_limit: number;
   cpu_limit: number;
+  security?: SecurityProfile;
+  networks: string[];
+  storage: StorageConfig;
+  health_check?: HealthCheck;
+  install?: InstallConfig;
   status: string;
+  health?: "starting" | "healthy" | "unhealthy";
+  install_status?: "running" | "success" | "failed";
+  address?: string;
+  game_status?: GameStatus;
   created_at: string;
   updated_at: string;
 }
 
+export interface SecurityProfile {
+  user?: string;
+  cap_drop_all: boolean;
+  cap_add?: string[];
+  no_new_privileges: boolean;
+  read_only_rootfs: boolean;
+  tmpfs?: Record<string, string>;
+  seccomp?: string;
+}
+
+export interface StorageConfig {
+  mode?: "bind" | "volume";
+  driver?: string;
+  driver_opts?: Record<string, string>;
+}
+
+export interface HealthCheck {
+  type: "command" | "tcp" | "query";
+  command?: string[];
+  port?: string;
+  interval?: string;
+  timeout?: string;
+  retries?: number;
+  start_period?: string;
+}
+
+export interface InstallConfig {
+  image: string;
+  script: string;
+  entrypoint?: string;
+  env?: Record<string, string>;
+  mount_path?: string;
+}
+
+export interface ServerInstall {
+  id: string;
+  server_id: string;
+  status: "running" | "success" | "failed";
+  exit_code: number;
+  error?: string;
+  started_at: string;
+  finished_at?: string;
+}
+
 export interface ConfigField {
   key: string;
   label: string;
@@ -27,21 +86,80 @@ export interface ConfigField {
   default: string;
   description: string;
   options?: string[];
-  env_var: string;
+  env_var?: string;
+  required?: boolean;
+  min?: number;
+  max?: number;
+  pattern?: string;
+  file?: string;
+  file_key?: string;
+  file_format?: "properties" | "json" | "yaml" | "ini";
+  remove?: boolean;
 }
 
 export interface GameTemplate {
   id: string;
+  version?: number;
+  extends?: string;
   name: string;
   game: string;
   description: string;
   image: string;
-  ports: string[];
+  images?: Record<string, string>;
+  ports: PortMapping[];
   env: Record<string, string>;
   volumes: Record<string, string>;
   memory: string;
   cpu: number;
   config_fields: ConfigField[];
+  security?: SecurityProfile;
+  storage?: StorageConfig;
+  health_check?: HealthCheck;
+  adapter?: AdapterSpec;
+  shell?: string;
+  install?: InstallConfig;
+  startup?: string;
+}
+
+export interface AdapterSpec {
+  patterns: {
+    join?: string;
+    leave?: string;
+    chat?: string;
+    error?: string;
+  };
+  commands: {
+    stop?: string;
+    list?: string;
+    save?: string;
+  };
+}
+
+export interface LogEvent {
+  type: "player_join" | "player_leave" | "chat" | "info" | "error";
+  player?: string;
+  message?: string;
+}
+
+export interface AdapterTestResult {
+  line: string;
+  event: LogEvent | null;
+}
+
+export interface TemplateChange {
+  path: string;
+  from?: unknown;
+  to?: unknown;
+}
+
+export interface UpgradePlan {
+  template_id: string;
+  from_version: number;
+  to_version: number;
+  template: TemplateChange[];
+  server: TemplateChange[];
+  errors?: Record<string, string>;
+  reinstall_recommended: boolean;
 }
 
 export interface CreateServerRequest {
@@ -50,6 +168,9 @@ export interface CreateServerRequest {
   env: Record<string, string>;
   memory: string;
   cpu: number;
+  networks?: string[];
+  storage?: StorageConfig;
+  image?: string;
 }
 
 export type ServerStatus = "running" | "exited" | "created" | "paused" | "restarting" | "dead" | "unknown";
@@ -79,6 +200,66 @@ export interface CreateScheduleRequest {
   action: string;
 }
 
+export interface GameStatus {
+  version?: string;
+  motd?: string;
+  online: number;
+  max: number;
+  players?: string[];
+  latency_ms: number;
+  error?: string;
+  checked_at: string;
+}
+
+export interface PlayerSession {
+  id: number;
+  player: string;
+  joined_at: string;
+  left_at: string | null;
+  duration: number;
+}
+
+export interface GameConfigFile {
+  path: string;
+  format: "properties" | "ini" | "json" | "yaml" | "text";
+  description?: string;
+}
+
+export interface GameCapabilities {
+  game: string;
+  adapter: boolean;
+  players: boolean;
+  commands: boolean;
+  probe: boolean;
+  status: boolean;
+  moderation: boolean;
+  save: boolean;
+  broadcast: boolean;
+  worlds: boolean;
+  create_world: boolean;
+  player_lists: string[];
+  config_files: GameConfigFile[];
+}
+
+export interface ListEntry {
+  name?: string;
+  id?: string;
+  level?: string;
+  reason?: string;
+}
+
+export interface World {
+  name: string;
+  size: number;
+  mod_time: string;
+  active: boolean;
+}
+
+export interface OnlinePlayers {
+  count: number;
+  players: PlayerSession[];
+}
+
 export interface ServerStats {
   id: number;
   server_id: string;